@@ -4,22 +4,35 @@ import (
 	"context"
 	"errors"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/conductorone/baton-sdk/pkg/pagination"
 	teleport "github.com/gravitational/teleport/api/client"
 	"github.com/gravitational/teleport/api/client/proto"
+	machineidv1 "github.com/gravitational/teleport/api/gen/proto/go/teleport/machineid/v1"
+	userspb "github.com/gravitational/teleport/api/gen/proto/go/teleport/users/v1"
 	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/api/types/accesslist"
+	"github.com/gravitational/teleport/api/types/userloginstate"
+	"github.com/gravitational/trace"
 )
 
 type TeleportClient struct {
 	*teleport.Client
 	ProxyAddress string
+
+	creds  teleport.Credentials
+	mu     sync.Mutex
+	leaves map[string]*TeleportClient
 }
 
 var ErrNoKeyProvided = errors.New("no key provided")
 
-const initTimeout = time.Duration(10) * time.Second
+const (
+	initTimeout     = time.Duration(10) * time.Second
+	defaultPageSize = 100
+)
 
 func New(ctx context.Context, proxyAddress, keyFile, key string) (*TeleportClient, error) {
 	if !hasPort(proxyAddress) {
@@ -51,9 +64,59 @@ func New(ctx context.Context, proxyAddress, keyFile, key string) (*TeleportClien
 	}
 
 	tc.Client = client
+	tc.creds = creds
 	return tc, nil
 }
 
+// LeafClient returns a client for the Auth Service of a leaf cluster,
+// reached through this cluster's proxy with the same identity. The proxy
+// must have TLS routing enabled. Clients are kept and reused until Close.
+func (t *TeleportClient) LeafClient(ctx context.Context, clusterName string) (*TeleportClient, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if leaf, ok := t.leaves[clusterName]; ok {
+		return leaf, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, initTimeout)
+	defer cancel()
+
+	client, err := teleport.New(ctx, teleport.Config{
+		Addrs:                      []string{t.ProxyAddress},
+		Credentials:                []teleport.Credentials{t.creds},
+		ALPNSNIAuthDialClusterName: clusterName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	leaf := &TeleportClient{
+		Client:       client,
+		ProxyAddress: t.ProxyAddress,
+		creds:        t.creds,
+	}
+	if t.leaves == nil {
+		t.leaves = map[string]*TeleportClient{}
+	}
+	t.leaves[clusterName] = leaf
+	return leaf, nil
+}
+
+// Close closes the clients of every leaf cluster and then this client.
+func (t *TeleportClient) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var errs []error
+	for name, leaf := range t.leaves {
+		errs = append(errs, leaf.Client.Close())
+		delete(t.leaves, name)
+	}
+	errs = append(errs, t.Client.Close())
+	return errors.Join(errs...)
+}
+
 func hasPort(address string) bool {
 	// remove https and http if it has it
 	address = strings.TrimPrefix(address, "https://")
@@ -67,3 +130,245 @@ func (t *TeleportClient) GetNodes(ctx context.Context, token *pagination.Token)
 		StartKey:     token.Token,
 	})
 }
+
+func (t *TeleportClient) GetUsersPage(ctx context.Context, token *pagination.Token) ([]*types.UserV2, string, error) {
+	resp, err := t.ListUsers(ctx, &userspb.ListUsersRequest{
+		PageSize:  int32(pageSize(token)),
+		PageToken: token.Token,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return resp.Users, resp.NextPageToken, nil
+}
+
+// ForEachUser calls fn with every user in the cluster, one page at a time,
+// without holding all users in memory.
+func (t *TeleportClient) ForEachUser(ctx context.Context, fn func(types.User) error) error {
+	token := &pagination.Token{}
+	for {
+		page, next, err := t.GetUsersPage(ctx, token)
+		if err != nil {
+			return err
+		}
+		for _, user := range page {
+			if err := fn(user); err != nil {
+				return err
+			}
+		}
+		if next == "" {
+			return nil
+		}
+		token.Token = next
+	}
+}
+
+func (t *TeleportClient) GetRolesPage(ctx context.Context, token *pagination.Token) ([]*types.RoleV6, string, error) {
+	resp, err := t.ListRoles(ctx, &proto.ListRolesRequest{
+		Limit:    int32(pageSize(token)),
+		StartKey: token.Token,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return resp.Roles, resp.NextKey, nil
+}
+
+// ForEachRole calls fn with every role in the cluster, one page at a time.
+func (t *TeleportClient) ForEachRole(ctx context.Context, fn func(types.Role) error) error {
+	token := &pagination.Token{}
+	for {
+		page, next, err := t.GetRolesPage(ctx, token)
+		if err != nil {
+			return err
+		}
+		for _, role := range page {
+			if err := fn(role); err != nil {
+				return err
+			}
+		}
+		if next == "" {
+			return nil
+		}
+		token.Token = next
+	}
+}
+
+func (t *TeleportClient) GetKubeClusters(ctx context.Context, token *pagination.Token) ([]types.KubeCluster, string, error) {
+	return t.ListKubernetesClusters(ctx, pageSize(token), token.Token)
+}
+
+func (t *TeleportClient) GetWindowsDesktopsPage(ctx context.Context, token *pagination.Token) (*types.ListWindowsDesktopsResponse, error) {
+	return t.ListWindowsDesktops(ctx, types.ListWindowsDesktopsRequest{
+		Limit:    pageSize(token),
+		StartKey: token.Token,
+	})
+}
+
+func (t *TeleportClient) GetAccessListsPage(ctx context.Context, token *pagination.Token) ([]*accesslist.AccessList, string, error) {
+	return t.AccessListClient().ListAccessLists(ctx, pageSize(token), token.Token)
+}
+
+func (t *TeleportClient) GetAccessListMembersPage(ctx context.Context, accessList string, token *pagination.Token) ([]*accesslist.AccessListMember, string, error) {
+	return t.AccessListClient().ListAccessListMembers(ctx, accessList, pageSize(token), token.Token)
+}
+
+// GetAllAccessLists returns every Access List in the cluster.
+func (t *TeleportClient) GetAllAccessLists(ctx context.Context) ([]*accesslist.AccessList, error) {
+	var lists []*accesslist.AccessList
+	token := &pagination.Token{}
+	for {
+		page, next, err := t.GetAccessListsPage(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, page...)
+		if next == "" {
+			return lists, nil
+		}
+		token.Token = next
+	}
+}
+
+// GetAllAccessListMembers returns every member of the named Access List.
+func (t *TeleportClient) GetAllAccessListMembers(ctx context.Context, accessList string) ([]*accesslist.AccessListMember, error) {
+	var members []*accesslist.AccessListMember
+	token := &pagination.Token{}
+	for {
+		page, next, err := t.GetAccessListMembersPage(ctx, accessList, token)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, page...)
+		if next == "" {
+			return members, nil
+		}
+		token.Token = next
+	}
+}
+
+// GetAllUserLoginStates returns the login state of every user that has one.
+func (t *TeleportClient) GetAllUserLoginStates(ctx context.Context) ([]*userloginstate.UserLoginState, error) {
+	var states []*userloginstate.UserLoginState
+	next := ""
+	for {
+		page, token, err := t.UserLoginStateClient().ListUserLoginStates(ctx, defaultPageSize, next)
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, page...)
+		if token == "" {
+			return states, nil
+		}
+		next = token
+	}
+}
+
+func (t *TeleportClient) GetLocksPage(ctx context.Context, token *pagination.Token) ([]types.Lock, string, error) {
+	return t.ListLocks(ctx, pageSize(token), token.Token, nil)
+}
+
+// GetInForceLocks returns the locks currently in force that match any of
+// targets, or every lock in force when no target is given.
+func (t *TeleportClient) GetInForceLocks(ctx context.Context, targets ...types.LockTarget) ([]types.Lock, error) {
+	var locks []types.Lock
+	filter := &types.LockFilter{InForceOnly: true}
+	for _, target := range targets {
+		filter.Targets = append(filter.Targets, &target)
+	}
+	next := ""
+	for {
+		page, token, err := t.ListLocks(ctx, defaultPageSize, next, filter)
+		if err != nil {
+			return nil, err
+		}
+		locks = append(locks, page...)
+		if token == "" {
+			return locks, nil
+		}
+		next = token
+	}
+}
+
+// GetProvisionTokensPage returns a page of the join tokens stored in the
+// backend. Static tokens from the Auth Service configuration are not
+// included; they come from GetStaticTokens. Auth Services that do not support
+// listing tokens return all of them from GetTokens in a single page.
+func (t *TeleportClient) GetProvisionTokensPage(ctx context.Context, token *pagination.Token) ([]types.ProvisionToken, string, error) {
+	tokens, next, err := t.ListProvisionTokens(ctx, pageSize(token), token.Token, nil, "")
+	if trace.IsNotImplemented(err) && token.Token == "" {
+		tokens, err = t.GetTokens(ctx) //nolint:staticcheck // Fallback for Auth Services without ListProvisionTokens.
+		return tokens, "", err
+	}
+	return tokens, next, err
+}
+
+func (t *TeleportClient) GetSAMLConnectorsPage(ctx context.Context, token *pagination.Token) ([]types.SAMLConnector, string, error) {
+	return t.ListSAMLConnectorsWithOptions(ctx, pageSize(token), token.Token, false)
+}
+
+func (t *TeleportClient) GetOIDCConnectorsPage(ctx context.Context, token *pagination.Token) ([]types.OIDCConnector, string, error) {
+	return t.ListOIDCConnectors(ctx, pageSize(token), token.Token, false)
+}
+
+func (t *TeleportClient) GetGithubConnectorsPage(ctx context.Context, token *pagination.Token) ([]types.GithubConnector, string, error) {
+	return t.ListGithubConnectors(ctx, pageSize(token), token.Token, false)
+}
+
+func (t *TeleportClient) GetTrustedClustersPage(ctx context.Context, token *pagination.Token) ([]types.TrustedCluster, string, error) {
+	return t.ListTrustedClusters(ctx, pageSize(token), token.Token)
+}
+
+func (t *TeleportClient) GetRemoteClustersPage(ctx context.Context, token *pagination.Token) ([]types.RemoteCluster, string, error) {
+	return t.ListRemoteClusters(ctx, pageSize(token), token.Token)
+}
+
+func (t *TeleportClient) GetBotsPage(ctx context.Context, token *pagination.Token) (*machineidv1.ListBotsResponse, error) {
+	return t.BotServiceClient().ListBots(ctx, &machineidv1.ListBotsRequest{
+		PageSize:  int32(pageSize(token)),
+		PageToken: token.Token,
+	})
+}
+
+// GetAllBots returns every Machine ID bot in the cluster.
+func (t *TeleportClient) GetAllBots(ctx context.Context) ([]*machineidv1.Bot, error) {
+	var bots []*machineidv1.Bot
+	token := &pagination.Token{}
+	for {
+		resp, err := t.GetBotsPage(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+		bots = append(bots, resp.GetBots()...)
+		if resp.GetNextPageToken() == "" {
+			return bots, nil
+		}
+		token.Token = resp.GetNextPageToken()
+	}
+}
+
+// GetAllBotInstances returns the instances of the named bot, or of every bot
+// in the cluster when botName is empty, paging through them in a single
+// sweep.
+func (t *TeleportClient) GetAllBotInstances(ctx context.Context, botName string) ([]*machineidv1.BotInstance, error) {
+	var instances []*machineidv1.BotInstance
+	req := &machineidv1.ListBotInstancesRequest{FilterBotName: botName, PageSize: defaultPageSize}
+	for {
+		resp, err := t.BotInstanceServiceClient().ListBotInstances(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		instances = append(instances, resp.GetBotInstances()...)
+		if resp.GetNextPageToken() == "" {
+			return instances, nil
+		}
+		req.PageToken = resp.GetNextPageToken()
+	}
+}
+
+func pageSize(token *pagination.Token) int {
+	if token.Size > 0 {
+		return token.Size
+	}
+	return defaultPageSize
+}
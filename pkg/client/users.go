@@ -0,0 +1,89 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/trace"
+)
+
+const (
+	userUpdateAttempts     = 5
+	userUpdateInitialDelay = 100 * time.Millisecond
+	userUpdateMaxDelay     = 2 * time.Second
+)
+
+// UserMutation applies an intended change to a freshly read user and reports
+// whether the user was changed. It may be called more than once, so it must
+// only depend on the user passed to it.
+type UserMutation func(user types.User) (bool, error)
+
+// userStore reads users and writes them back conditionally. TeleportClient
+// implements it against the Auth Service.
+type userStore interface {
+	GetUser(ctx context.Context, name string, withSecrets bool) (types.User, error)
+	ConditionalUpdateUser(ctx context.Context, user types.User) (types.User, error)
+}
+
+// ConditionalUpdateUser writes user only if the stored user is still at the
+// revision user was read at, and fails with a CompareFailed error otherwise.
+// The users API has no conditional write, so the stored revision is compared
+// right before the update, which carries the read revision.
+func (t *TeleportClient) ConditionalUpdateUser(ctx context.Context, user types.User) (types.User, error) {
+	current, err := t.GetUser(ctx, user.GetName(), false)
+	if err != nil {
+		return nil, err
+	}
+	if current.GetRevision() != user.GetRevision() {
+		return nil, trace.CompareFailed("user %s was modified since it was read", user.GetName())
+	}
+	return t.UpdateUser(ctx, user)
+}
+
+// MutateUser reads a user, applies mutate and writes the result back. The
+// write is conditional on the revision the user was read at, so it fails if
+// the user changed in between; on such a conflict the user is read again and
+// mutate is re-applied to the fresh copy, backing off between attempts. It
+// returns the updated user, or the unchanged user when mutate reports no
+// change.
+func (t *TeleportClient) MutateUser(ctx context.Context, name string, mutate UserMutation) (types.User, bool, error) {
+	return mutateUser(ctx, t, name, mutate)
+}
+
+func mutateUser(ctx context.Context, store userStore, name string, mutate UserMutation) (types.User, bool, error) {
+	delay := userUpdateInitialDelay
+	for attempt := 1; ; attempt++ {
+		user, err := store.GetUser(ctx, name, false)
+		if err != nil {
+			return nil, false, err
+		}
+
+		changed, err := mutate(user)
+		if err != nil {
+			return nil, false, err
+		}
+		if !changed {
+			return user, false, nil
+		}
+
+		updated, err := store.ConditionalUpdateUser(ctx, user)
+		if err == nil {
+			return updated, true, nil
+		}
+		if !trace.IsCompareFailed(err) {
+			return nil, false, err
+		}
+		if attempt == userUpdateAttempts {
+			return nil, false, fmt.Errorf("user %s was modified concurrently %d times: %w", name, attempt, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, userUpdateMaxDelay)
+	}
+}
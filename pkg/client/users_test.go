@@ -0,0 +1,95 @@
+package client
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeUserStore keeps a single user and bumps its revision on every write.
+// onUpdate runs before each conditional update, to simulate concurrent
+// writers.
+type fakeUserStore struct {
+	user     types.User
+	revision int
+	updates  int
+	onUpdate func(store *fakeUserStore)
+}
+
+func (f *fakeUserStore) GetUser(_ context.Context, name string, _ bool) (types.User, error) {
+	if name != f.user.GetName() {
+		return nil, trace.NotFound("user %s not found", name)
+	}
+	return f.user.Clone(), nil
+}
+
+func (f *fakeUserStore) ConditionalUpdateUser(_ context.Context, user types.User) (types.User, error) {
+	f.updates++
+	if f.onUpdate != nil {
+		f.onUpdate(f)
+	}
+	if user.GetRevision() != f.user.GetRevision() {
+		return nil, trace.CompareFailed("user %s was modified since it was read", user.GetName())
+	}
+	f.write(user)
+	return f.user.Clone(), nil
+}
+
+func (f *fakeUserStore) write(user types.User) {
+	f.revision++
+	f.user = user.Clone()
+	f.user.SetRevision(strconv.Itoa(f.revision))
+}
+
+func TestMutateUser_RetriesOnCompareFailed(t *testing.T) {
+	user, err := types.NewUser("alice")
+	require.NoError(t, err)
+	user.SetRoles([]string{"access"})
+
+	store := &fakeUserStore{}
+	store.write(user)
+
+	// Another writer adds the auditor role between the first read and write.
+	store.onUpdate = func(f *fakeUserStore) {
+		if f.updates == 1 {
+			concurrent := f.user.Clone()
+			concurrent.AddRole("auditor")
+			f.write(concurrent)
+		}
+	}
+
+	calls := 0
+	updated, changed, err := mutateUser(context.Background(), store, "alice", func(user types.User) (bool, error) {
+		calls++
+		user.AddRole("dev")
+		return true, nil
+	})
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Equal(t, 2, calls)
+	require.Equal(t, 2, store.updates)
+
+	roles := updated.GetRoles()
+	slices.Sort(roles)
+	require.Equal(t, []string{"access", "auditor", "dev"}, roles)
+}
+
+func TestMutateUser_NoChange(t *testing.T) {
+	user, err := types.NewUser("alice")
+	require.NoError(t, err)
+
+	store := &fakeUserStore{}
+	store.write(user)
+
+	_, changed, err := mutateUser(context.Background(), store, "alice", func(types.User) (bool, error) {
+		return false, nil
+	})
+	require.NoError(t, err)
+	require.False(t, changed)
+	require.Zero(t, store.updates)
+}
@@ -7,6 +7,9 @@ type Teleport struct {
 	TeleportProxyAddress string `mapstructure:"teleport-proxy-address"`
 	TeleportKeyPath string `mapstructure:"teleport-key-path"`
 	TeleportKey string `mapstructure:"teleport-key"`
+	RoleRuleEntitlements bool `mapstructure:"role-rule-entitlements"`
+	LeafClusters bool `mapstructure:"leaf-clusters"`
+	AccessListMembershipTtl string `mapstructure:"access-list-membership-ttl"`
 }
 
 func (c *Teleport) findFieldByTag(tagValue string) (any, bool) {
@@ -17,6 +17,18 @@ var (
 		field.WithRequired(true),
 		field.WithDescription("The fully-qualified teleport proxy service to connect with. Example: \"baton.teleport.sh:443\"."),
 	)
+	RoleRuleEntitlementsField = field.BoolField(
+		"role-rule-entitlements",
+		field.WithDescription("Model each resource rule verb of a role, such as user:create, as its own entitlement."),
+	)
+	LeafClustersField = field.BoolField(
+		"leaf-clusters",
+		field.WithDescription("Also sync the users, roles and resources of every leaf cluster, through the root cluster's proxy."),
+	)
+	AccessListMembershipTTLField = field.StringField(
+		"access-list-membership-ttl",
+		field.WithDescription("The longest an access list membership granted by the connector lasts, such as \"720h\", and its duration when the grant request sets no expiry."),
+	)
 
 	fieldRelationships = []field.SchemaFieldRelationship{
 		field.FieldsMutuallyExclusive(TeleportKeyFilePathField, TeleportKeyField),
@@ -27,6 +39,9 @@ var (
 		ProxyAddressField,
 		TeleportKeyFilePathField,
 		TeleportKeyField,
+		RoleRuleEntitlementsField,
+		LeafClustersField,
+		AccessListMembershipTTLField,
 	}
 )
 
@@ -39,6 +39,16 @@ func TestConfigSchema(t *testing.T) {
 				true,
 				"private key",
 			},
+			{
+				"--teleport-proxy-address 1 --teleport-key 1 --role-rule-entitlements",
+				true,
+				"role rule entitlements",
+			},
+			{
+				"--teleport-proxy-address 1 --teleport-key 1 --leaf-clusters",
+				true,
+				"leaf clusters",
+			},
 		},
 	)
 }
@@ -0,0 +1,513 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/gravitational/teleport/api/types"
+)
+
+// labelsProfileKey is the resource profile key holding the Teleport labels
+// (static and dynamic) of a synced resource, so grants can be computed
+// without fetching the resource again.
+const labelsProfileKey = "labels"
+
+// traitTemplateRe matches role values such as "{{internal.logins}}",
+// "db-{{external.team}}" or `{{external["groups"]}}`. Template functions
+// (email.local, regexp.replace...) are not supported.
+var traitTemplateRe = regexp.MustCompile(`^(.*?)\{\{\s*(?:internal|external)(?:\.([\w\-]+)|\["([^"]+)"\])\s*\}\}(.*)$`)
+
+// rolesAllowingLabels returns the roles that grant access to a resource of
+// the given kind carrying labels.
+func rolesAllowingLabels(roles []types.Role, kind string, labels map[string]string) []types.Role {
+	var rv []types.Role
+	for _, role := range roles {
+		if roleAllowsLabels(role, kind, labels) {
+			rv = append(rv, role)
+		}
+	}
+	return rv
+}
+
+// rolesDenyingLabels returns the roles whose deny rules apply to a resource
+// of the given kind carrying labels, because their deny selector matches it.
+func rolesDenyingLabels(roles []types.Role, kind string, labels map[string]string) []types.Role {
+	var rv []types.Role
+	for _, role := range roles {
+		deny, err := role.GetLabelMatchers(types.Deny, kind)
+		if err == nil && matchLabels(deny.Labels, labels) {
+			rv = append(rv, role)
+		}
+	}
+	return rv
+}
+
+// roleAllowsLabels reports whether role grants access to a resource of the
+// given kind carrying labels: the allow selector must match and the deny
+// selector must not. Label expressions are not evaluated.
+func roleAllowsLabels(role types.Role, kind string, labels map[string]string) bool {
+	allow, err := role.GetLabelMatchers(types.Allow, kind)
+	if err != nil || !matchLabels(allow.Labels, labels) {
+		return false
+	}
+
+	deny, err := role.GetLabelMatchers(types.Deny, kind)
+	if err != nil {
+		return false
+	}
+
+	return !matchLabels(deny.Labels, labels)
+}
+
+// matchLabels mirrors Teleport's label selector semantics: an empty selector
+// matches nothing, {"*": ["*"]} matches everything, and otherwise every
+// selector key must be present on the target with a matching value.
+func matchLabels(selector types.Labels, labels map[string]string) bool {
+	if len(selector) == 0 {
+		return false
+	}
+
+	if values := selector[types.Wildcard]; len(values) == 1 && values[0] == types.Wildcard {
+		return true
+	}
+
+	for key, values := range selector {
+		value, ok := labels[key]
+		if !ok {
+			return false
+		}
+		if !matchLabelValues(values, value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchLabelValues reports whether value matches any of the selector values.
+// Values may be a literal, a glob using "*", or a regular expression wrapped
+// in "^" and "$". Trait templates such as "{{internal.env}}" cannot be
+// resolved without a user and never match.
+func matchLabelValues(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if pattern == types.Wildcard {
+			return true
+		}
+		if isTraitTemplate(pattern) {
+			continue
+		}
+
+		re, err := labelValueRegexp(pattern)
+		if err != nil {
+			continue
+		}
+		if re.MatchString(value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func labelValueRegexp(pattern string) (*regexp.Regexp, error) {
+	if !strings.HasPrefix(pattern, "^") || !strings.HasSuffix(pattern, "$") {
+		pattern = "^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, "(.*)") + "$"
+	}
+	return regexp.Compile(pattern)
+}
+
+func isTraitTemplate(value string) bool {
+	return strings.Contains(value, "{{") && strings.Contains(value, "}}")
+}
+
+// expandTraitTemplate resolves a role value containing a trait template
+// against a user's traits. It returns nil when the template is unsupported or
+// the user has no such trait.
+func expandTraitTemplate(value string, traits map[string][]string) []string {
+	m := traitTemplateRe.FindStringSubmatch(value)
+	if m == nil {
+		return nil
+	}
+
+	name := m[2]
+	if name == "" {
+		name = m[3]
+	}
+
+	var rv []string
+	for _, traitValue := range traits[name] {
+		if traitValue == "" {
+			continue
+		}
+		rv = append(rv, m[1]+traitValue+m[4])
+	}
+	return rv
+}
+
+// valueAccess records who may use a single role value (an OS login, a
+// database user...) on a resource: roles granting it to every member, and
+// users receiving it through a trait template on one of their roles.
+type valueAccess struct {
+	roles []string
+	users []string
+}
+
+// resolveValueAccess maps every value allowed by roles to the principals
+// holding it. values returns the allow or deny list of a role; a value denied
+// by the same role, or a deny wildcard, drops it. Teleport also denies a
+// value to a user when any other of the user's roles among denyRoles, the
+// roles whose deny rules apply to the resource, denies it. A role is only
+// granted a value if none of its members is denied it; otherwise the members
+// that still hold the value are granted it directly.
+func resolveValueAccess(
+	roles []types.Role,
+	denyRoles []types.Role,
+	members roleMemberIndex,
+	values func(types.Role, types.RoleConditionType) []string,
+) map[string]*valueAccess {
+	rv := make(map[string]*valueAccess)
+	get := func(value string) *valueAccess {
+		if _, ok := rv[value]; !ok {
+			rv[value] = &valueAccess{}
+		}
+		return rv[value]
+	}
+
+	denies := userDenies(slices.Concat(roles, denyRoles), members, values)
+
+	for _, role := range roles {
+		deny := values(role, types.Deny)
+		if slices.Contains(deny, types.Wildcard) {
+			continue
+		}
+
+		roleUsers := members.users(role.GetName())
+		for _, value := range values(role, types.Allow) {
+			if !isTraitTemplate(value) {
+				if value == "" || slices.Contains(deny, value) {
+					continue
+				}
+
+				allowed := slices.DeleteFunc(slices.Clone(roleUsers), func(user roleMember) bool {
+					return denies.denied(user.name, value)
+				})
+				access := get(value)
+				if len(allowed) == len(roleUsers) {
+					access.roles = appendUnique(access.roles, role.GetName())
+					continue
+				}
+				for _, user := range allowed {
+					access.users = appendUnique(access.users, user.name)
+				}
+				continue
+			}
+
+			for _, user := range roleUsers {
+				for _, expanded := range expandTraitTemplate(value, user.traits) {
+					if slices.Contains(deny, expanded) || denies.denied(user.name, expanded) {
+						continue
+					}
+					access := get(expanded)
+					access.users = appendUnique(access.users, user.name)
+				}
+			}
+		}
+	}
+
+	for value, access := range rv {
+		if len(access.roles) == 0 && len(access.users) == 0 {
+			delete(rv, value)
+		}
+	}
+
+	return rv
+}
+
+// deniedValues maps a user name to the values denied to the user by any of
+// their roles, with trait templates expanded.
+type deniedValues map[string]map[string]bool
+
+func (d deniedValues) denied(userName, value string) bool {
+	return d[userName][value] || d[userName][types.Wildcard]
+}
+
+// userDenies collects, for every user, the values denied by each of the
+// user's roles among denyRoles.
+func userDenies(
+	denyRoles []types.Role,
+	members roleMemberIndex,
+	values func(types.Role, types.RoleConditionType) []string,
+) deniedValues {
+	byName := make(map[string]types.Role, len(denyRoles))
+	for _, role := range denyRoles {
+		byName[role.GetName()] = role
+	}
+
+	rv := make(deniedValues)
+	for roleName, role := range byName {
+		deny := values(role, types.Deny)
+		if len(deny) == 0 {
+			continue
+		}
+		for _, user := range members.users(roleName) {
+			for _, value := range deny {
+				expanded := []string{value}
+				if isTraitTemplate(value) {
+					expanded = expandTraitTemplate(value, user.traits)
+				}
+				for _, v := range expanded {
+					if rv[user.name] == nil {
+						rv[user.name] = map[string]bool{}
+					}
+					rv[user.name][v] = true
+				}
+			}
+		}
+	}
+	return rv
+}
+
+func appendUnique(values []string, value string) []string {
+	if slices.Contains(values, value) {
+		return values
+	}
+	return append(values, value)
+}
+
+// roleValueKind describes one kind of role value, such as database users or
+// Kubernetes groups, modelled as one entitlement per value on a resource.
+type roleValueKind struct {
+	// prefix is the entitlement slug prefix, e.g. "db_user".
+	prefix string
+	// description names the value in display names, e.g. "database user".
+	description string
+	// values returns the allow or deny list of a role.
+	values func(types.Role, types.RoleConditionType) []string
+}
+
+// resourceAccess holds the principals that can reach a resource and, per
+// value kind prefix, the holders of each value the matching roles allow on
+// it. Grants name roles and users in the resource's cluster.
+type resourceAccess struct {
+	scope   *clusterScope
+	members *valueAccess
+	values  map[string]map[string]*valueAccess
+}
+
+// resolveMemberAccess returns the principals that can reach a resource: every
+// role in roles, the roles allowing it, unless one of the role's members also
+// holds a role among denyRoles, whose deny selector matches the resource. In
+// that case the role is dropped and its members that are not denied are
+// granted access directly.
+func resolveMemberAccess(roles []types.Role, denyRoles []types.Role, members roleMemberIndex) *valueAccess {
+	denied := make(map[string]bool)
+	for _, role := range denyRoles {
+		for _, user := range members.users(role.GetName()) {
+			denied[user.name] = true
+		}
+	}
+
+	rv := &valueAccess{}
+	for _, role := range roles {
+		roleUsers := members.users(role.GetName())
+		allowed := slices.DeleteFunc(slices.Clone(roleUsers), func(user roleMember) bool {
+			return denied[user.name]
+		})
+		if len(allowed) == len(roleUsers) {
+			rv.roles = appendUnique(rv.roles, role.GetName())
+			continue
+		}
+		for _, user := range allowed {
+			rv.users = appendUnique(rv.users, user.name)
+		}
+	}
+	return rv
+}
+
+// resolveResourceAccess evaluates every role against the labels of a synced
+// resource of the given kind and resolves who can reach it, and the values of
+// each value kind for the matching roles, expanding trait templates against
+// each member's traits. Teleport does not expose a reliable "who can reach
+// this resource" API, so access is derived from the role specs the same way
+// the Teleport RBAC engine does it, using the cluster's index for the sync.
+func resolveResourceAccess(
+	ctx context.Context,
+	clusters *clusterSet,
+	resource *v2.Resource,
+	kind string,
+	valueKinds []roleValueKind,
+	syncID string,
+) (*resourceAccess, error) {
+	scope, err := clusters.resourceScope(ctx, resource)
+	if err != nil {
+		return nil, err
+	}
+	index := clusters.index(scope, syncID)
+
+	labels, err := getResourceLabels(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	allRoles, err := index.Roles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	roles := rolesAllowingLabels(allRoles, kind, labels)
+	denyRoles := rolesDenyingLabels(allRoles, kind, labels)
+
+	rv := &resourceAccess{
+		scope:  scope,
+		values: make(map[string]map[string]*valueAccess, len(valueKinds)),
+	}
+	if len(denyRoles) == 0 && len(valueKinds) == 0 {
+		// Without denies nor values, the roles alone are enough and the
+		// members need not be loaded.
+		rv.members = resolveMemberAccess(roles, nil, nil)
+		return rv, nil
+	}
+
+	members, err := index.Members(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rv.members = resolveMemberAccess(roles, denyRoles, members)
+	for _, vk := range valueKinds {
+		rv.values[vk.prefix] = resolveValueAccess(roles, denyRoles, members, vk.values)
+	}
+
+	return rv, nil
+}
+
+// entitlements returns one assignment entitlement per resolved value, sorted
+// so that repeated syncs produce a stable order.
+func (a *resourceAccess) entitlements(resource *v2.Resource, valueKinds []roleValueKind) []*v2.Entitlement {
+	var rv []*v2.Entitlement
+	for _, vk := range valueKinds {
+		access := a.values[vk.prefix]
+		values := make([]string, 0, len(access))
+		for value := range access {
+			values = append(values, value)
+		}
+		sort.Strings(values)
+
+		for _, value := range values {
+			rv = append(rv, ent.NewAssignmentEntitlement(
+				resource,
+				valueEntitlementName(vk.prefix, value),
+				ent.WithGrantableTo(userResourceType, roleResourceType),
+				ent.WithDisplayName(fmt.Sprintf("%s %s %s", resource.DisplayName, vk.description, value)),
+				ent.WithDescription(fmt.Sprintf("Access to Teleport resource %s as %s %s", resource.DisplayName, vk.description, value)),
+			))
+		}
+	}
+	return rv
+}
+
+// grants returns the membership grants of the principals reaching the
+// resource and the grants for every resolved value: expandable grants to
+// roles, and direct grants to users holding access through a trait template
+// or despite a deny on another of their roles. Resources without a membership
+// entitlement pass an empty membership.
+func (a *resourceAccess) grants(resource *v2.Resource, membership string, valueKinds []roleValueKind) []*v2.Grant {
+	var rv []*v2.Grant
+	if membership != "" && a.members != nil {
+		rv = append(rv, a.holderGrants(resource, membership, a.members)...)
+	}
+
+	for _, vk := range valueKinds {
+		for value, holders := range a.values[vk.prefix] {
+			rv = append(rv, a.holderGrants(resource, valueEntitlementName(vk.prefix, value), holders)...)
+		}
+	}
+	return rv
+}
+
+// holderGrants grants entitlementName on resource to every role and user
+// among holders.
+func (a *resourceAccess) holderGrants(resource *v2.Resource, entitlementName string, holders *valueAccess) []*v2.Grant {
+	var rv []*v2.Grant
+	for _, roleName := range holders.roles {
+		rv = append(rv, newRoleGrant(resource, entitlementName, a.scope.resourceID(roleName)))
+	}
+	for _, userName := range holders.users {
+		rv = append(rv, grant.NewGrant(resource, entitlementName, &v2.ResourceId{
+			ResourceType: userResourceType.Id,
+			Resource:     a.scope.resourceID(userName),
+		}))
+	}
+	return rv
+}
+
+// valueEntitlementName builds the entitlement slug for one value of a kind of
+// role value, e.g. "db_user:postgres".
+func valueEntitlementName(prefix, value string) string {
+	return fmt.Sprintf("%s:%s", prefix, value)
+}
+
+// labelsProfile converts resource labels into a profile value.
+func labelsProfile(labels map[string]string) map[string]interface{} {
+	rv := make(map[string]interface{}, len(labels))
+	for k, v := range labels {
+		rv[k] = v
+	}
+	return rv
+}
+
+// getResourceLabels reads the labels stored in the role trait profile of a
+// synced resource.
+func getResourceLabels(resource *v2.Resource) (map[string]string, error) {
+	trait, err := rs.GetRoleTrait(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := make(map[string]string)
+	for k, v := range trait.GetProfile().GetFields()[labelsProfileKey].GetStructValue().GetFields() {
+		labels[k] = v.GetStringValue()
+	}
+	return labels, nil
+}
+
+// roleEntitlementGrants emits a grant on the resource entitlement for every
+// role that can reach a resource of the given kind. Grants are expandable
+// through role membership so the users holding each role inherit access.
+func roleEntitlementGrants(
+	ctx context.Context,
+	clusters *clusterSet,
+	resource *v2.Resource,
+	entitlementName string,
+	kind string,
+	syncID string,
+) ([]*v2.Grant, error) {
+	access, err := resolveResourceAccess(ctx, clusters, resource, kind, nil, syncID)
+	if err != nil {
+		return nil, err
+	}
+
+	return access.grants(resource, entitlementName, nil), nil
+}
+
+// newRoleGrant builds a grant of entitlementName on resource to a Teleport
+// role, expanded to the members of that role. roleID is the resource ID of
+// the role, which is its name in the root cluster.
+func newRoleGrant(resource *v2.Resource, entitlementName, roleID string, opts ...grant.GrantOption) *v2.Grant {
+	principal := &v2.ResourceId{
+		ResourceType: roleResourceType.Id,
+		Resource:     roleID,
+	}
+
+	opts = append(opts, grant.WithAnnotation(&v2.GrantExpandable{
+		EntitlementIds: []string{fmt.Sprintf("%s:%s:%s", roleResourceType.Id, roleID, roleMembership)},
+	}))
+	return grant.NewGrant(resource, entitlementName, principal, opts...)
+}
@@ -0,0 +1,474 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/api/types/accesslist"
+	"github.com/gravitational/teleport/api/types/header"
+	"github.com/gravitational/teleport/api/types/userloginstate"
+	"github.com/gravitational/trace"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+
+	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/conductorone/baton-teleport/pkg/client"
+)
+
+const (
+	accessListMembership = "member"
+	accessListOwnership  = "owner"
+	// accessListExpiresKey is the grant metadata key holding the expiry of a
+	// time-bound membership request.
+	accessListExpiresKey = "expires"
+)
+
+type accessListBuilder struct {
+	resourceType  *v2.ResourceType
+	client        *client.TeleportClient
+	membershipTTL time.Duration
+}
+
+func (a *accessListBuilder) ResourceType(_ context.Context) *v2.ResourceType {
+	return a.resourceType
+}
+
+// Create a new connector resource for a Teleport Access List.
+func getAccessListResource(list *accesslist.AccessList) (*v2.Resource, error) {
+	displayName := list.Spec.Title
+	if displayName == "" {
+		displayName = list.GetName()
+	}
+
+	return rs.NewGroupResource(
+		displayName,
+		accessListResourceType,
+		list.GetName(),
+		[]rs.GroupTraitOption{
+			rs.WithGroupProfile(
+				map[string]interface{}{
+					"access_list_name": list.GetName(),
+					"title":            list.Spec.Title,
+					"description":      list.Spec.Description,
+					"type":             string(list.Spec.Type),
+					"granted_roles":    stringsToInterfaces(list.GetGrants().Roles),
+					"owner_roles":      stringsToInterfaces(list.GetOwnerGrants().Roles),
+					"next_audit_date":  formatTime(list.Spec.Audit.NextAuditDate),
+					"membership_roles": stringsToInterfaces(list.GetMembershipRequires().Roles),
+					"ownership_roles":  stringsToInterfaces(list.GetOwnershipRequires().Roles),
+					labelsProfileKey:   labelsProfile(list.GetAllLabels()),
+				},
+			),
+		},
+	)
+}
+
+// List returns all the Access Lists from Teleport as resource objects.
+func (a *accessListBuilder) List(ctx context.Context, _ *v2.ResourceId, opts rs.SyncOpAttrs) ([]*v2.Resource, *rs.SyncOpResults, error) {
+	var rv []*v2.Resource
+	lists, nextToken, err := a.client.GetAccessListsPage(ctx, &pagination.Token{Token: opts.PageToken.Token, Size: opts.PageToken.Size})
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to list access lists: %w", err)
+	}
+
+	for _, list := range lists {
+		rr, err := getAccessListResource(list)
+		if err != nil {
+			return nil, nil, fmt.Errorf("baton-teleport: failed to create access list resource: %w", err)
+		}
+		rv = append(rv, rr)
+	}
+
+	return rv, &rs.SyncOpResults{NextPageToken: nextToken}, nil
+}
+
+func (a *accessListBuilder) Get(ctx context.Context, resourceId *v2.ResourceId, _ *v2.ResourceId) (*v2.Resource, annotations.Annotations, error) {
+	if resourceId == nil {
+		return nil, nil, fmt.Errorf("baton-teleport: resourceId is required")
+	}
+
+	list, err := a.client.AccessListClient().GetAccessList(ctx, resourceId.Resource)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to get access list %s: %w", resourceId.Resource, err)
+	}
+
+	res, err := getAccessListResource(list)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return res, nil, nil
+}
+
+// Entitlements returns the member and owner entitlements of an Access List.
+// Both can be held by users directly or by the members of a nested list.
+func (a *accessListBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ rs.SyncOpAttrs) ([]*v2.Entitlement, *rs.SyncOpResults, error) {
+	return []*v2.Entitlement{
+		ent.NewAssignmentEntitlement(
+			resource,
+			accessListMembership,
+			ent.WithGrantableTo(userResourceType, accessListResourceType),
+			ent.WithDisplayName(fmt.Sprintf("%s Access List %s", resource.DisplayName, accessListMembership)),
+			ent.WithDescription(fmt.Sprintf("Member of %s Teleport access list", resource.DisplayName)),
+		),
+		ent.NewAssignmentEntitlement(
+			resource,
+			accessListOwnership,
+			ent.WithGrantableTo(userResourceType, accessListResourceType),
+			ent.WithDisplayName(fmt.Sprintf("%s Access List %s", resource.DisplayName, accessListOwnership)),
+			ent.WithDescription(fmt.Sprintf("Owner of %s Teleport access list", resource.DisplayName)),
+		),
+	}, nil, nil
+}
+
+// Grants returns the owners of the list on the first page, followed by its
+// members one page at a time. Members and owners that are themselves Access
+// Lists are granted to the nested list and expand to its members.
+func (a *accessListBuilder) Grants(ctx context.Context, resource *v2.Resource, opts rs.SyncOpAttrs) ([]*v2.Grant, *rs.SyncOpResults, error) {
+	var rv []*v2.Grant
+	listName := resource.Id.Resource
+
+	list, err := a.client.AccessListClient().GetAccessList(ctx, listName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to get access list %s: %w", listName, err)
+	}
+	nextReviewDate := formatTime(list.Spec.Audit.NextAuditDate)
+
+	if opts.PageToken.Token == "" {
+		for _, owner := range list.GetOwners() {
+			rv = append(rv, newAccessListGrant(resource, accessListOwnership, owner.Name, owner.IsMembershipKindUser(), map[string]interface{}{
+				"next_review_date": nextReviewDate,
+			}))
+		}
+	}
+
+	members, nextToken, err := a.client.GetAccessListMembersPage(ctx, listName, &pagination.Token{Token: opts.PageToken.Token, Size: opts.PageToken.Size})
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to list members of access list %s: %w", listName, err)
+	}
+
+	for _, member := range members {
+		rv = append(rv, newAccessListGrant(resource, accessListMembership, member.Spec.Name, member.IsUser(), accessListMemberMetadata(member, nextReviewDate)))
+	}
+
+	return rv, &rs.SyncOpResults{NextPageToken: nextToken}, nil
+}
+
+// Grant adds the principal to the list's members, or to its owners for the
+// owner entitlement. An Access List principal is added as a nested list. A
+// user principal must satisfy the list's membership_requires (or
+// ownership_requires) conditions. A membership expires as the time-bound
+// grant request asks, within the configured membership TTL. Granting to an existing member or owner is reported
+// with a GrantAlreadyExists annotation and leaves the member untouched.
+func (a *accessListBuilder) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) ([]*v2.Grant, annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+	listName := entitlement.Resource.Id.Resource
+	memberName := principal.Id.Resource
+
+	membershipKind, err := accessListMembershipKind(principal.Id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	list, err := a.client.AccessListClient().GetAccessList(ctx, listName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to get access list %s: %w", listName, err)
+	}
+
+	slug := entitlementSlug(entitlement)
+	requires := list.GetMembershipRequires()
+	if slug == accessListOwnership {
+		requires = list.GetOwnershipRequires()
+	}
+	if membershipKind == accesslist.MembershipKindUser && !requires.IsEmpty() {
+		user, err := a.client.GetUser(ctx, memberName, false)
+		if err != nil {
+			return nil, nil, fmt.Errorf("baton-teleport: failed to get user %s: %w", memberName, err)
+		}
+		// A user that never logged in, or a cluster without login states, is
+		// checked against the user's own roles and traits.
+		state, err := a.client.UserLoginStateClient().GetUserLoginState(ctx, memberName)
+		if err != nil && !trace.IsNotFound(err) && !isUnavailable(err) {
+			return nil, nil, fmt.Errorf("baton-teleport: failed to get login state of user %s: %w", memberName, err)
+		}
+		if err := checkAccessListRequires(requires, user, state); err != nil {
+			return nil, nil, fmt.Errorf("baton-teleport: user %s does not meet the %s requirements of access list %s: %w", memberName, slug, listName, err)
+		}
+	}
+
+	if slug == accessListOwnership {
+		changed, err := a.updateOwners(ctx, listName, func(owners []accesslist.Owner) ([]accesslist.Owner, bool) {
+			for _, owner := range owners {
+				if owner.Name == memberName {
+					return owners, false
+				}
+			}
+			return append(owners, accesslist.Owner{Name: memberName, MembershipKind: membershipKind}), true
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("baton-teleport: failed to add owner to access list %s: %w", listName, err)
+		}
+		if !changed {
+			l.Info("Access list ownership already exists.", zap.String("access_list", listName), zap.String("owner", memberName))
+			return nil, annotations.New(&v2.GrantAlreadyExists{}), nil
+		}
+
+		l.Info("Access list ownership has been granted.", zap.String("access_list", listName), zap.String("owner", memberName))
+		return nil, nil, nil
+	}
+
+	_, err = a.client.AccessListClient().GetAccessListMember(ctx, listName, memberName)
+	if err == nil {
+		l.Info("Access list membership already exists.", zap.String("access_list", listName), zap.String("member", memberName))
+		return nil, annotations.New(&v2.GrantAlreadyExists{}), nil
+	}
+	if !trace.IsNotFound(err) {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to get member %s of access list %s: %w", memberName, listName, err)
+	}
+
+	expires, err := accessListMembershipExpiry(entitlement, a.membershipTTL, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	addedBy, err := a.client.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to get current user: %w", err)
+	}
+
+	member, err := accesslist.NewAccessListMember(
+		header.Metadata{Name: memberName},
+		accesslist.AccessListMemberSpec{
+			AccessList:     listName,
+			Name:           memberName,
+			Joined:         time.Now().UTC(),
+			Expires:        expires,
+			AddedBy:        addedBy.GetName(),
+			MembershipKind: membershipKind,
+		},
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to build access list member: %w", err)
+	}
+
+	if _, err := a.client.AccessListClient().UpsertAccessListMember(ctx, member); err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to add member to access list %s: %w", listName, err)
+	}
+
+	l.Info("Access list membership has been granted.",
+		zap.String("access_list", listName),
+		zap.String("member", memberName),
+		zap.Time("expires", expires),
+	)
+	return nil, nil, nil
+}
+
+// Revoke removes the principal from the list's members, or from its owners
+// for the owner entitlement. Revoking from a principal that is not a member
+// or owner is reported with a GrantAlreadyRevoked annotation.
+func (a *accessListBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+	listName := grant.Entitlement.Resource.Id.Resource
+	memberName := grant.Principal.Id.Resource
+
+	if _, err := accessListMembershipKind(grant.Principal.Id); err != nil {
+		return nil, err
+	}
+
+	if entitlementSlug(grant.Entitlement) == accessListOwnership {
+		changed, err := a.updateOwners(ctx, listName, func(owners []accesslist.Owner) ([]accesslist.Owner, bool) {
+			var rv []accesslist.Owner
+			for _, owner := range owners {
+				if owner.Name != memberName {
+					rv = append(rv, owner)
+				}
+			}
+			return rv, len(rv) != len(owners)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("baton-teleport: failed to remove owner from access list %s: %w", listName, err)
+		}
+		if !changed {
+			l.Info("Access list ownership already revoked.", zap.String("access_list", listName), zap.String("owner", memberName))
+			return annotations.New(&v2.GrantAlreadyRevoked{}), nil
+		}
+
+		l.Info("Access list ownership has been revoked.", zap.String("access_list", listName), zap.String("owner", memberName))
+		return nil, nil
+	}
+
+	if err := a.client.AccessListClient().DeleteAccessListMember(ctx, listName, memberName); err != nil {
+		if trace.IsNotFound(err) {
+			l.Info("Access list membership already revoked.", zap.String("access_list", listName), zap.String("member", memberName))
+			return annotations.New(&v2.GrantAlreadyRevoked{}), nil
+		}
+		return nil, fmt.Errorf("baton-teleport: failed to remove member from access list %s: %w", listName, err)
+	}
+
+	l.Info("Access list membership has been revoked.", zap.String("access_list", listName), zap.String("member", memberName))
+	return nil, nil
+}
+
+// updateOwners replaces the owners of an Access List with the result of
+// update, and reports whether update changed them. The list is written back
+// with the revision it was read at, and only when the owners changed.
+func (a *accessListBuilder) updateOwners(ctx context.Context, listName string, update func([]accesslist.Owner) ([]accesslist.Owner, bool)) (bool, error) {
+	list, err := a.client.AccessListClient().GetAccessList(ctx, listName)
+	if err != nil {
+		return false, err
+	}
+
+	owners, changed := update(list.GetOwners())
+	if !changed {
+		return false, nil
+	}
+
+	list.SetOwners(owners)
+	if _, err := a.client.AccessListClient().UpdateAccessList(ctx, list); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// accessListMembershipExpiry returns when a membership granted through
+// entitlement expires. The SDK does not pass the grant request's own
+// annotations to Grant, so a time-bound request is read from the expires
+// grant metadata value (RFC 3339) of the entitlement it targets. The TTL, when
+// set, caps the requested expiry and applies when none is requested. The zero
+// time means the membership does not expire.
+func accessListMembershipExpiry(entitlement *v2.Entitlement, ttl time.Duration, now time.Time) (time.Time, error) {
+	var limit time.Time
+	if ttl > 0 {
+		limit = now.Add(ttl).UTC()
+	}
+
+	metadata := &v2.GrantMetadata{}
+	annos := annotations.Annotations(entitlement.GetAnnotations())
+	ok, err := annos.Pick(metadata)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("baton-teleport: failed to read grant metadata: %w", err)
+	}
+	value, _ := metadata.GetMetadata().AsMap()[accessListExpiresKey].(string)
+	if !ok || value == "" {
+		return limit, nil
+	}
+
+	expires, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("baton-teleport: invalid access list membership expiry %q: %w", value, err)
+	}
+	if !expires.After(now) {
+		return time.Time{}, fmt.Errorf("baton-teleport: access list membership expiry %s is in the past", value)
+	}
+	if !limit.IsZero() && expires.After(limit) {
+		return limit, nil
+	}
+	return expires.UTC(), nil
+}
+
+// checkAccessListRequires reports the first role or trait value in requires
+// that the user lacks. As in Teleport, every listed role and every listed
+// value of each trait must be present. Roles and traits are the user's
+// effective ones: its own plus those its login state carries, such as roles
+// granted by other Access Lists. state is nil for a user without one.
+func checkAccessListRequires(requires accesslist.Requires, user types.User, state *userloginstate.UserLoginState) error {
+	sources := &roleSources{loginStates: map[string]*userloginstate.UserLoginState{}}
+	traits := map[string][]string{}
+	for name, values := range user.GetTraits() {
+		traits[name] = slices.Clone(values)
+	}
+	if state != nil {
+		sources.loginStates[user.GetName()] = state
+		for name, values := range state.GetTraits() {
+			for _, value := range values {
+				traits[name] = appendUnique(traits[name], value)
+			}
+		}
+	}
+
+	roles := sources.effectiveRoles(user)
+	for _, role := range requires.Roles {
+		if !slices.Contains(roles, role) {
+			return fmt.Errorf("missing role %q", role)
+		}
+	}
+
+	for name, values := range requires.Traits {
+		for _, value := range values {
+			if !slices.Contains(traits[name], value) {
+				return fmt.Errorf("missing trait %s=%q", name, value)
+			}
+		}
+	}
+
+	return nil
+}
+
+// accessListMembershipKind maps a grant principal to the Access List
+// membership kind it is stored as.
+func accessListMembershipKind(principal *v2.ResourceId) (string, error) {
+	switch principal.ResourceType {
+	case userResourceType.Id:
+		return accesslist.MembershipKindUser, nil
+	case accessListResourceType.Id:
+		return accesslist.MembershipKindList, nil
+	default:
+		return "", fmt.Errorf("baton-teleport: only users and access lists can be members of an access list")
+	}
+}
+
+// newAccessListGrant grants entitlementName on the list to a user, or to a
+// nested Access List whose members inherit it through expansion.
+func newAccessListGrant(resource *v2.Resource, entitlementName, principalName string, isUser bool, metadata map[string]interface{}) *v2.Grant {
+	opts := []grant.GrantOption{grant.WithGrantMetadata(metadata)}
+	principalType := userResourceType.Id
+	if !isUser {
+		principalType = accessListResourceType.Id
+		opts = append(opts, grant.WithAnnotation(&v2.GrantExpandable{
+			EntitlementIds: []string{fmt.Sprintf("%s:%s:%s", accessListResourceType.Id, principalName, accessListMembership)},
+		}))
+	}
+
+	return grant.NewGrant(
+		resource,
+		entitlementName,
+		&v2.ResourceId{ResourceType: principalType, Resource: principalName},
+		opts...,
+	)
+}
+
+// accessListMemberMetadata surfaces when a member joined, when the
+// membership expires, who added it and when the list is next due for review.
+func accessListMemberMetadata(member *accesslist.AccessListMember, nextReviewDate string) map[string]interface{} {
+	return map[string]interface{}{
+		"next_review_date": nextReviewDate,
+		"joined":           formatTime(member.Spec.Joined),
+		"expires":          formatTime(member.Spec.Expires),
+		"reason":           member.Spec.Reason,
+		"added_by":         member.Spec.AddedBy,
+	}
+}
+
+// formatTime renders t as RFC 3339, or an empty string when t is unset.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func newAccessListBuilder(c *client.TeleportClient, membershipTTL time.Duration) *accessListBuilder {
+	return &accessListBuilder{
+		resourceType:  accessListResourceType,
+		client:        c,
+		membershipTTL: membershipTTL,
+	}
+}
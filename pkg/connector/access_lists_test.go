@@ -0,0 +1,162 @@
+package connector
+
+import (
+	"testing"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/api/types/accesslist"
+	"github.com/gravitational/teleport/api/types/header"
+	"github.com/gravitational/teleport/api/types/trait"
+	"github.com/gravitational/teleport/api/types/userloginstate"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestNewAccessListGrant_NestedListIsExpandable(t *testing.T) {
+	resource := &v2.Resource{Id: &v2.ResourceId{ResourceType: accessListResourceType.Id, Resource: "engineering"}}
+	g := newAccessListGrant(resource, accessListMembership, "platform", false, nil)
+
+	require.Equal(t, accessListResourceType.Id, g.Principal.Id.ResourceType)
+	require.Equal(t, "platform", g.Principal.Id.Resource)
+
+	expandable := &v2.GrantExpandable{}
+	annos := annotations.Annotations(g.Annotations)
+	ok, err := annos.Pick(expandable)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, []string{"access_list:platform:member"}, expandable.EntitlementIds)
+}
+
+func TestNewAccessListGrant_UserIsNotExpandable(t *testing.T) {
+	resource := &v2.Resource{Id: &v2.ResourceId{ResourceType: accessListResourceType.Id, Resource: "engineering"}}
+	g := newAccessListGrant(resource, accessListOwnership, "alice", true, nil)
+
+	require.Equal(t, userResourceType.Id, g.Principal.Id.ResourceType)
+	annos := annotations.Annotations(g.Annotations)
+	require.False(t, annos.Contains(&v2.GrantExpandable{}))
+}
+
+func TestAccessListMemberMetadata(t *testing.T) {
+	joined := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	member, err := accesslist.NewAccessListMember(
+		header.Metadata{Name: "alice"},
+		accesslist.AccessListMemberSpec{
+			AccessList: "engineering",
+			Name:       "alice",
+			Joined:     joined,
+			AddedBy:    "admin",
+		},
+	)
+	require.NoError(t, err)
+
+	metadata := accessListMemberMetadata(member, "2024-06-01T00:00:00Z")
+	require.Equal(t, "2024-03-01T09:00:00Z", metadata["joined"])
+	require.Equal(t, "", metadata["expires"])
+	require.Equal(t, "admin", metadata["added_by"])
+	require.Equal(t, "2024-06-01T00:00:00Z", metadata["next_review_date"])
+}
+
+func TestAccessListMembershipKind(t *testing.T) {
+	kind, err := accessListMembershipKind(&v2.ResourceId{ResourceType: userResourceType.Id, Resource: "alice"})
+	require.NoError(t, err)
+	require.Equal(t, accesslist.MembershipKindUser, kind)
+
+	kind, err = accessListMembershipKind(&v2.ResourceId{ResourceType: accessListResourceType.Id, Resource: "platform"})
+	require.NoError(t, err)
+	require.Equal(t, accesslist.MembershipKindList, kind)
+
+	_, err = accessListMembershipKind(&v2.ResourceId{ResourceType: roleResourceType.Id, Resource: "dev"})
+	require.Error(t, err)
+}
+
+func TestCheckAccessListRequires(t *testing.T) {
+	user, err := types.NewUser("alice")
+	require.NoError(t, err)
+	user.SetRoles([]string{"access", "dev"})
+	user.SetTraits(map[string][]string{"team": {"payments", "infra"}})
+
+	tests := []struct {
+		name     string
+		requires accesslist.Requires
+		wantErr  string
+	}{
+		{"role held", accesslist.Requires{Roles: []string{"dev"}}, ""},
+		{"role missing", accesslist.Requires{Roles: []string{"dev", "auditor"}}, `missing role "auditor"`},
+		{"trait values held", accesslist.Requires{Traits: trait.Traits{"team": {"payments", "infra"}}}, ""},
+		{"trait value missing", accesslist.Requires{Traits: trait.Traits{"team": {"security"}}}, `missing trait team="security"`},
+		{"trait missing", accesslist.Requires{Traits: trait.Traits{"region": {"eu"}}}, `missing trait region="eu"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkAccessListRequires(tt.requires, user, nil)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestAccessListMembershipExpiry(t *testing.T) {
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	entitlement := func(expires string) *v2.Entitlement {
+		e := &v2.Entitlement{}
+		if expires != "" {
+			e.Annotations = annotations.New(&v2.GrantMetadata{
+				Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+					accessListExpiresKey: structpb.NewStringValue(expires),
+				}},
+			})
+		}
+		return e
+	}
+
+	expires, err := accessListMembershipExpiry(entitlement(""), 0, now)
+	require.NoError(t, err)
+	require.True(t, expires.IsZero())
+
+	expires, err = accessListMembershipExpiry(entitlement(""), 720*time.Hour, now)
+	require.NoError(t, err)
+	require.Equal(t, now.Add(720*time.Hour), expires)
+
+	// The requested expiry wins within the TTL and is capped by it.
+	expires, err = accessListMembershipExpiry(entitlement("2024-03-02T09:00:00Z"), 720*time.Hour, now)
+	require.NoError(t, err)
+	require.Equal(t, now.Add(24*time.Hour), expires)
+
+	expires, err = accessListMembershipExpiry(entitlement("2025-03-01T09:00:00Z"), 720*time.Hour, now)
+	require.NoError(t, err)
+	require.Equal(t, now.Add(720*time.Hour), expires)
+
+	_, err = accessListMembershipExpiry(entitlement("2024-02-01T09:00:00Z"), 0, now)
+	require.Error(t, err)
+
+	_, err = accessListMembershipExpiry(entitlement("tomorrow"), 0, now)
+	require.Error(t, err)
+}
+
+func TestCheckAccessListRequires_LoginState(t *testing.T) {
+	user, err := types.NewUser("alice")
+	require.NoError(t, err)
+	user.SetRoles([]string{"access"})
+
+	state, err := userloginstate.New(header.Metadata{Name: "alice"}, userloginstate.Spec{
+		Roles:            []string{"access", "auditor"},
+		AccessListRoles:  []string{"auditor"},
+		Traits:           trait.Traits{"team": {"security"}},
+		AccessListTraits: trait.Traits{"team": {"security"}},
+	})
+	require.NoError(t, err)
+
+	requires := accesslist.Requires{
+		Roles:  []string{"auditor"},
+		Traits: trait.Traits{"team": {"security"}},
+	}
+	require.EqualError(t, checkAccessListRequires(requires, user, nil), `missing role "auditor"`)
+	require.NoError(t, checkAccessListRequires(requires, user, state))
+}
@@ -0,0 +1,325 @@
+package connector
+
+import (
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/stretchr/testify/require"
+)
+
+// newRoleMembers indexes users by the roles set on them.
+func newRoleMembers(users ...types.User) roleMemberIndex {
+	rv := roleMemberIndex{}
+	for _, user := range users {
+		rv.addUser(&v2.ResourceId{ResourceType: userResourceType.Id, Resource: user.GetName()}, user, &roleSources{})
+	}
+	return rv
+}
+
+func TestMatchLabels(t *testing.T) {
+	labels := map[string]string{"env": "prod", "team": "payments-eu"}
+
+	tests := []struct {
+		name     string
+		selector types.Labels
+		want     bool
+	}{
+		{"empty selector matches nothing", types.Labels{}, false},
+		{"full wildcard", types.Labels{"*": {"*"}}, true},
+		{"literal value", types.Labels{"env": {"prod"}}, true},
+		{"literal mismatch", types.Labels{"env": {"staging"}}, false},
+		{"missing key", types.Labels{"region": {"*"}}, false},
+		{"value wildcard", types.Labels{"env": {"*"}}, true},
+		{"glob value", types.Labels{"team": {"payments-*"}}, true},
+		{"regex value", types.Labels{"team": {"^payments-(eu|us)$"}}, true},
+		{"regex mismatch", types.Labels{"team": {"^payments-us$"}}, false},
+		{"all keys must match", types.Labels{"env": {"prod"}, "team": {"infra"}}, false},
+		{"any value may match", types.Labels{"env": {"staging", "prod"}}, true},
+		{"trait template never matches", types.Labels{"env": {"{{internal.env}}"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, matchLabels(tt.selector, labels))
+		})
+	}
+}
+
+func TestRoleAllowsLabels_DenyWins(t *testing.T) {
+	role, err := types.NewRole("prod-access", types.RoleSpecV6{
+		Allow: types.RoleConditions{NodeLabels: types.Labels{"*": {"*"}}},
+		Deny:  types.RoleConditions{NodeLabels: types.Labels{"env": {"prod"}}},
+	})
+	require.NoError(t, err)
+
+	require.True(t, roleAllowsLabels(role, types.KindNode, map[string]string{"env": "dev"}))
+	require.False(t, roleAllowsLabels(role, types.KindNode, map[string]string{"env": "prod"}))
+}
+
+func TestGetResourceLabels_RoundTrip(t *testing.T) {
+	resource, err := getNodeResource(&Node{
+		Id:     "node-1",
+		Name:   "db-01",
+		Labels: map[string]string{"env": "prod"},
+	})
+	require.NoError(t, err)
+
+	labels, err := getResourceLabels(resource)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"env": "prod"}, labels)
+}
+
+func TestGetNodeResource_RevisionAlias(t *testing.T) {
+	resource, err := getNodeResource(&Node{Id: "node-1", Name: "db-01", Revision: "rev-1"})
+	require.NoError(t, err)
+	require.Equal(t, "node-1", resource.Id.Resource)
+
+	aliases := &v2.Aliases{}
+	annos := annotations.Annotations(resource.Annotations)
+	ok, err := annos.Pick(aliases)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, []string{"rev-1"}, aliases.Ids)
+}
+
+func TestNewRoleGrant_IsExpandable(t *testing.T) {
+	resource := &v2.Resource{Id: &v2.ResourceId{ResourceType: nodeResourceType.Id, Resource: "node-1"}}
+	g := newRoleGrant(resource, nodeMembership, "dev")
+
+	require.Equal(t, roleResourceType.Id, g.Principal.Id.ResourceType)
+	require.Equal(t, "dev", g.Principal.Id.Resource)
+
+	expandable := &v2.GrantExpandable{}
+	require.True(t, g.Annotations[0].MessageIs(expandable))
+	require.NoError(t, g.Annotations[0].UnmarshalTo(expandable))
+	require.Equal(t, []string{"role:dev:member"}, expandable.EntitlementIds)
+}
+
+func TestRoleAllowsLabels_AppLabels(t *testing.T) {
+	role, err := types.NewRole("grafana-viewers", types.RoleSpecV6{
+		Allow: types.RoleConditions{AppLabels: types.Labels{"app": {"grafana"}}},
+	})
+	require.NoError(t, err)
+
+	require.True(t, roleAllowsLabels(role, types.KindApp, map[string]string{"app": "grafana"}))
+	require.False(t, roleAllowsLabels(role, types.KindApp, map[string]string{"app": "jenkins"}))
+	require.False(t, roleAllowsLabels(role, types.KindNode, map[string]string{"app": "grafana"}))
+}
+
+func TestExpandTraitTemplate(t *testing.T) {
+	traits := map[string][]string{
+		"db_users": {"alice", "readonly"},
+		"team":     {"payments"},
+	}
+
+	require.Equal(t, []string{"alice", "readonly"}, expandTraitTemplate("{{internal.db_users}}", traits))
+	require.Equal(t, []string{"svc-payments"}, expandTraitTemplate("svc-{{external.team}}", traits))
+	require.Equal(t, []string{"payments"}, expandTraitTemplate(`{{external["team"]}}`, traits))
+	require.Nil(t, expandTraitTemplate("{{internal.logins}}", traits))
+	require.Nil(t, expandTraitTemplate("{{email.local(external.email)}}", traits))
+}
+
+func TestResolveValueAccess_DatabaseUsers(t *testing.T) {
+	dba, err := types.NewRole("dba", types.RoleSpecV6{
+		Allow: types.RoleConditions{
+			DatabaseLabels: types.Labels{"*": {"*"}},
+			DatabaseUsers:  []string{"postgres", "{{internal.db_users}}"},
+		},
+		Deny: types.RoleConditions{
+			DatabaseUsers: []string{"superuser"},
+		},
+	})
+	require.NoError(t, err)
+
+	alice, err := types.NewUser("alice")
+	require.NoError(t, err)
+	alice.SetRoles([]string{"dba"})
+	alice.SetTraits(map[string][]string{"db_users": {"alice", "superuser"}})
+
+	bob, err := types.NewUser("bob")
+	require.NoError(t, err)
+	bob.SetRoles([]string{"access"})
+	bob.SetTraits(map[string][]string{"db_users": {"bob"}})
+
+	access := resolveValueAccess([]types.Role{dba}, nil, newRoleMembers(alice, bob), types.Role.GetDatabaseUsers)
+
+	require.Len(t, access, 2)
+	require.Equal(t, []string{"dba"}, access["postgres"].roles)
+	require.Empty(t, access["postgres"].users)
+	require.Equal(t, []string{"alice"}, access["alice"].users)
+	require.NotContains(t, access, "superuser")
+	require.NotContains(t, access, "bob")
+}
+
+func TestKubeResourceValues(t *testing.T) {
+	role, err := types.NewRole("kube-dev", types.RoleSpecV6{
+		Allow: types.RoleConditions{
+			KubernetesLabels: types.Labels{"env": {"dev"}},
+			KubernetesResources: []types.KubernetesResource{
+				{Kind: "pods", Namespace: "dev", Name: "*", Verbs: []string{"get", "list"}},
+				{Kind: "deployments", APIGroup: "apps", Namespace: "*", Name: "*", Verbs: []string{"*"}},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	values := kubeResourceValues(role, types.Allow)
+	require.Contains(t, values, "pods:dev/*:get,list")
+	require.Contains(t, values, "apps/deployments:*/*:*")
+	require.True(t, roleAllowsLabels(role, types.KindKubernetesCluster, map[string]string{"env": "dev"}))
+}
+
+func TestResourceAccess_WindowsLoginsWithoutMembership(t *testing.T) {
+	role, err := types.NewRole("rdp", types.RoleSpecV6{
+		Allow: types.RoleConditions{
+			WindowsDesktopLabels: types.Labels{"*": {"*"}},
+			WindowsDesktopLogins: []string{"Administrator", "{{internal.windows_logins}}"},
+		},
+	})
+	require.NoError(t, err)
+
+	user, err := types.NewUser("carol")
+	require.NoError(t, err)
+	user.SetRoles([]string{"rdp"})
+	user.SetTraits(map[string][]string{"windows_logins": {"carol"}})
+
+	access := &resourceAccess{
+		values: map[string]map[string]*valueAccess{
+			"login": resolveValueAccess([]types.Role{role}, nil, newRoleMembers(user), types.Role.GetWindowsLogins),
+		},
+	}
+	resource := &v2.Resource{
+		Id:          &v2.ResourceId{ResourceType: windowsDesktopResourceType.Id, Resource: "jump-01"},
+		DisplayName: "jump-01",
+	}
+
+	entitlements := access.entitlements(resource, windowsDesktopValueKinds)
+	require.Len(t, entitlements, 2)
+	require.Equal(t, "login:Administrator", entitlements[0].Slug)
+	require.Equal(t, "login:carol", entitlements[1].Slug)
+
+	grants := access.grants(resource, "", windowsDesktopValueKinds)
+	require.Len(t, grants, 2)
+	for _, g := range grants {
+		switch g.Principal.Id.ResourceType {
+		case roleResourceType.Id:
+			require.Equal(t, "windows_desktop:jump-01:login:Administrator", g.Entitlement.Id)
+		case userResourceType.Id:
+			require.Equal(t, "carol", g.Principal.Id.Resource)
+			require.Equal(t, "windows_desktop:jump-01:login:carol", g.Entitlement.Id)
+		default:
+			t.Fatalf("unexpected principal type %s", g.Principal.Id.ResourceType)
+		}
+	}
+}
+
+func TestResolveValueAccess_NodeLogins(t *testing.T) {
+	role, err := types.NewRole("ssh", types.RoleSpecV6{
+		Allow: types.RoleConditions{
+			NodeLabels: types.Labels{"*": {"*"}},
+			Logins:     []string{"root", "{{internal.logins}}"},
+		},
+	})
+	require.NoError(t, err)
+
+	alice, err := types.NewUser("alice")
+	require.NoError(t, err)
+	alice.SetRoles([]string{"ssh"})
+	alice.SetLogins([]string{"alice", "deploy"})
+
+	access := resolveValueAccess([]types.Role{role}, nil, newRoleMembers(alice), types.Role.GetLogins)
+	require.Equal(t, []string{"ssh"}, access["root"].roles)
+	require.Equal(t, []string{"alice"}, access["alice"].users)
+	require.Equal(t, []string{"alice"}, access["deploy"].users)
+}
+
+func TestResolveValueAccess_DenyFromOtherRole(t *testing.T) {
+	ssh, err := types.NewRole("ssh", types.RoleSpecV6{
+		Allow: types.RoleConditions{
+			NodeLabels: types.Labels{"*": {"*"}},
+			Logins:     []string{"root", "ubuntu", "{{internal.logins}}"},
+		},
+	})
+	require.NoError(t, err)
+
+	noRoot, err := types.NewRole("no-root", types.RoleSpecV6{
+		Deny: types.RoleConditions{
+			NodeLabels: types.Labels{"env": {"prod"}},
+			Logins:     []string{"root", "{{internal.logins}}"},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, rolesDenyingLabels([]types.Role{ssh, noRoot}, types.KindNode, map[string]string{"env": "prod"}), 1)
+
+	alice, err := types.NewUser("alice")
+	require.NoError(t, err)
+	alice.SetRoles([]string{"ssh", "no-root"})
+	alice.SetLogins([]string{"alice"})
+
+	bob, err := types.NewUser("bob")
+	require.NoError(t, err)
+	bob.SetRoles([]string{"ssh"})
+	bob.SetLogins([]string{"bob"})
+
+	access := resolveValueAccess([]types.Role{ssh}, []types.Role{noRoot}, newRoleMembers(alice, bob), types.Role.GetLogins)
+
+	// alice is denied root by another role, so the ssh role no longer carries
+	// it and bob holds it directly.
+	require.Empty(t, access["root"].roles)
+	require.Equal(t, []string{"bob"}, access["root"].users)
+	require.Equal(t, []string{"ssh"}, access["ubuntu"].roles)
+	require.NotContains(t, access, "alice")
+	require.Equal(t, []string{"bob"}, access["bob"].users)
+}
+
+func TestResolveMemberAccess_DenyFromOtherRole(t *testing.T) {
+	ssh, err := types.NewRole("ssh", types.RoleSpecV6{
+		Allow: types.RoleConditions{NodeLabels: types.Labels{"*": {"*"}}},
+	})
+	require.NoError(t, err)
+
+	noProd, err := types.NewRole("no-prod", types.RoleSpecV6{
+		Deny: types.RoleConditions{NodeLabels: types.Labels{"env": {"prod"}}},
+	})
+	require.NoError(t, err)
+
+	alice, err := types.NewUser("alice")
+	require.NoError(t, err)
+	alice.SetRoles([]string{"ssh", "no-prod"})
+
+	bob, err := types.NewUser("bob")
+	require.NoError(t, err)
+	bob.SetRoles([]string{"ssh"})
+
+	roles := []types.Role{ssh, noProd}
+	members := newRoleMembers(alice, bob)
+
+	prod := map[string]string{"env": "prod"}
+	access := resolveMemberAccess(
+		rolesAllowingLabels(roles, types.KindNode, prod),
+		rolesDenyingLabels(roles, types.KindNode, prod),
+		members,
+	)
+	// alice is denied prod nodes by no-prod, so the ssh role no longer
+	// carries membership and bob is granted it directly.
+	require.Empty(t, access.roles)
+	require.Equal(t, []string{"bob"}, access.users)
+
+	dev := map[string]string{"env": "dev"}
+	access = resolveMemberAccess(
+		rolesAllowingLabels(roles, types.KindNode, dev),
+		rolesDenyingLabels(roles, types.KindNode, dev),
+		members,
+	)
+	require.Equal(t, []string{"ssh"}, access.roles)
+	require.Empty(t, access.users)
+
+	resource := &v2.Resource{Id: &v2.ResourceId{ResourceType: nodeResourceType.Id, Resource: "db-01"}}
+	grants := (&resourceAccess{members: &valueAccess{users: []string{"bob"}}}).grants(resource, nodeMembership, nil)
+	require.Len(t, grants, 1)
+	require.Equal(t, userResourceType.Id, grants[0].Principal.Id.ResourceType)
+	require.Equal(t, "bob", grants[0].Principal.Id.Resource)
+	require.Empty(t, grants[0].Annotations)
+}
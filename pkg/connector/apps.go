@@ -5,27 +5,29 @@ import (
 	"fmt"
 
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
 	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
 	"github.com/gravitational/teleport/api/types"
 
 	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
-	"github.com/conductorone/baton-teleport/pkg/client"
 )
 
 const appMembership = "member"
 
 type appBuilder struct {
 	resourceType *v2.ResourceType
-	client       *client.TeleportClient
+	clusters     *clusterSet
 }
 
 func (a *appBuilder) ResourceType(_ context.Context) *v2.ResourceType {
 	return a.resourceType
 }
 
-// Create a new connector resource for a Teleport node.
+// Create a new connector resource for a Teleport app.
+// App names are unique within a cluster and stable across edits, so they are
+// used as the resource ID; audit events reference apps by name as well.
 func getAppResource(app types.Application) (*v2.Resource, error) {
-	appId := app.GetMetadata().Revision
+	appId := app.GetName()
 	return rs.NewRoleResource(
 		app.GetName(),
 		appResourceType,
@@ -33,19 +35,31 @@ func getAppResource(app types.Application) (*v2.Resource, error) {
 		[]rs.RoleTraitOption{
 			rs.WithRoleProfile(
 				map[string]interface{}{
-					"app_id":   appId,
-					"app_name": app.GetName(),
+					"app_id":         appId,
+					"app_name":       app.GetName(),
+					"revision":       app.GetRevision(),
+					labelsProfileKey: labelsProfile(app.GetAllLabels()),
 				},
 			),
 		},
+		legacyIDOptions(app.GetRevision())...,
 	)
 }
 
 // List returns all the apps from the database as resource objects.
 // Apps include a NodeTrait because they are the 'shape' of a standard node.
-func (a *appBuilder) List(ctx context.Context, _ *v2.ResourceId, _ rs.SyncOpAttrs) ([]*v2.Resource, *rs.SyncOpResults, error) {
+func (a *appBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, _ rs.SyncOpAttrs) ([]*v2.Resource, *rs.SyncOpResults, error) {
 	var rv []*v2.Resource
-	apps, err := a.client.GetApps(ctx)
+	if a.clusters.skipList(a.resourceType.Id, parentResourceID) {
+		return nil, nil, nil
+	}
+
+	scope, err := a.clusters.scope(ctx, a.resourceType.Id, parentResourceID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	apps, err := scope.client.GetApps(ctx)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -56,31 +70,62 @@ func (a *appBuilder) List(ctx context.Context, _ *v2.ResourceId, _ rs.SyncOpAttr
 		if err != nil {
 			return nil, nil, err
 		}
-		rv = append(rv, rr)
+		rv = append(rv, scope.scopeResource(rr))
 	}
 
 	return rv, nil, nil
 }
 
+func (a *appBuilder) Get(ctx context.Context, resourceId *v2.ResourceId, _ *v2.ResourceId) (*v2.Resource, annotations.Annotations, error) {
+	if resourceId == nil {
+		return nil, nil, fmt.Errorf("baton-teleport: resourceId is required")
+	}
+
+	scope, name, err := a.clusters.scopeOf(ctx, a.resourceType.Id, resourceId.Resource)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	app, err := scope.client.GetApp(ctx, name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to get app %s: %w", resourceId.Resource, err)
+	}
+
+	res, err := getAppResource(app)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return scope.scopeResource(res), nil, nil
+}
+
 func (a *appBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ rs.SyncOpAttrs) ([]*v2.Entitlement, *rs.SyncOpResults, error) {
 	return []*v2.Entitlement{
 		ent.NewAssignmentEntitlement(
 			resource,
 			appMembership,
-			ent.WithGrantableTo(userResourceType),
+			ent.WithGrantableTo(userResourceType, roleResourceType),
 			ent.WithDisplayName(fmt.Sprintf("%s App %s", resource.DisplayName, appMembership)),
 			ent.WithDescription(fmt.Sprintf("Member of %s Teleport app", resource.DisplayName)),
 		),
 	}, nil, nil
 }
 
-func (a *appBuilder) Grants(_ context.Context, _ *v2.Resource, _ rs.SyncOpAttrs) ([]*v2.Grant, *rs.SyncOpResults, error) {
-	return nil, nil, nil
+// Grants returns a grant on the app for every role whose app_labels match the
+// app's labels and whose deny rules do not exclude it. The grants expand to
+// the members of each role.
+func (a *appBuilder) Grants(ctx context.Context, resource *v2.Resource, opts rs.SyncOpAttrs) ([]*v2.Grant, *rs.SyncOpResults, error) {
+	rv, err := roleEntitlementGrants(ctx, a.clusters, resource, appMembership, types.KindApp, opts.SyncID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to compute app grants: %w", err)
+	}
+
+	return rv, nil, nil
 }
 
-func newAppBuilder(c *client.TeleportClient) *appBuilder {
+func newAppBuilder(clusters *clusterSet) *appBuilder {
 	return &appBuilder{
 		resourceType: appResourceType,
-		client:       c,
+		clusters:     clusters,
 	}
 }
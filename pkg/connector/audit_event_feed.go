@@ -68,11 +68,24 @@ const (
 	// lock.created fires when a user is suspended via a Teleport lock.
 	// lock.deleted fires when the lock is removed (user re-enabled).
 	//
-	// Only user targets are relevant: Get() on a user surfaces IsLocked
-	// as STATUS_DISABLED/STATUS_ENABLED. Role targets are ignored because
-	// the role resource has no lock/status field — Get() returns nothing new.
+	// Only user targets are relevant: Get() on a user lists the locks in
+	// force targeting it and reports STATUS_DISABLED/STATUS_ENABLED. Role
+	// targets are ignored because the role resource has no lock/status
+	// field — Get() returns nothing new.
 	lockCreateEventType = "lock.created"
 	lockDeleteEventType = "lock.deleted"
+
+	// App and database events use bare present-tense strings. Both resource
+	// types are identified by name, which is what the events carry.
+	appCreateEventType = "app.create"
+	appUpdateEventType = "app.update"
+	dbCreateEventType  = "db.create"
+	dbUpdateEventType  = "db.update"
+
+	// Teleport has no node create/update audit events. instance.join fires
+	// when an agent joins the cluster and carries its host UUID, which is the
+	// node resource ID. Only joins with the Node system role are relevant.
+	instanceJoinEventType = "instance.join"
 )
 
 // resourceChangeEventTypes is the list of Teleport audit events that signal a
@@ -89,23 +102,24 @@ const (
 //
 // lock.deleted IS included (unlike other delete events) because it deletes
 // the *lock*, not the user. The user still exists and Get() returns them
-// with IsLocked=false → STATUS_ENABLED.
+// with no lock in force → STATUS_ENABLED.
 //
-// app.create, app.update, db.create, db.update are intentionally excluded:
-// List() uses Metadata.Revision as the resource ID for apps and databases,
-// but audit events only carry the resource name — C1 cannot correlate the
-// event to an existing resource. Until the ID strategy is resolved, these
-// resource types are excluded from incremental sync and reconciled during
-// the next full sync instead.
+// app.create, app.update, db.create and db.update are included: apps and
+// databases use their name as the resource ID, which is what the events carry.
+// instance.join is included for nodes, whose resource ID is the host UUID.
 var resourceChangeEventTypes = []string{
 	userCreateEventType, userUpdateEventType,
 	roleCreateEventType, roleUpdateEventType,
 	accessRequestReviewEventType, accessRequestUpdateEventType, accessRequestExpireEventType,
 	lockCreateEventType, lockDeleteEventType,
+	appCreateEventType, appUpdateEventType,
+	dbCreateEventType, dbUpdateEventType,
+	instanceJoinEventType,
 }
 
 type auditEventFeed struct {
-	client *client.TeleportClient
+	client   *client.TeleportClient
+	clusters *clusterSet
 }
 
 func (e *auditEventFeed) EventFeedMetadata(_ context.Context) *v2.EventFeedMetadata {
@@ -119,6 +133,11 @@ func (e *auditEventFeed) EventFeedMetadata(_ context.Context) *v2.EventFeedMetad
 	}
 }
 
+// ListEvents returns the next page of events from the audit log of the root
+// cluster and, with leaf clusters synced, of every leaf cluster, each read
+// through its own client from its own position in the cursor. A leaf cluster
+// that cannot be reached is logged and skipped; its position is kept, so its
+// events are read once it is back.
 func (e *auditEventFeed) ListEvents(
 	ctx context.Context,
 	earliestEvent *timestamppb.Timestamp,
@@ -126,20 +145,86 @@ func (e *auditEventFeed) ListEvents(
 ) ([]*v2.Event, *pagination.StreamState, annotations.Annotations, error) {
 	l := ctxzap.Extract(ctx)
 
-	cursor, err := unmarshalEventPageCursor(pToken, earliestEvent)
+	cursor, err := unmarshalAuditFeedCursor(pToken, earliestEvent)
 	if err != nil {
 		return nil, nil, nil, err
 	}
+	to := time.Now().UTC()
 
-	from, err := time.Parse(time.RFC3339Nano, cursor.StartAt)
+	result, hasMore, err := e.searchCluster(ctx, nil, &cursor.eventPageCursor, to)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("baton-teleport: failed to parse audit start_at: %w", err)
+		return nil, nil, nil, err
+	}
+
+	if e.clusters.leafClusters {
+		leaves, err := e.client.GetRemoteClusters(ctx)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("baton-teleport: failed to list remote clusters: %w", err)
+		}
+
+		// Positions are only kept for current leaf clusters, so a removed
+		// leaf drops out of the cursor.
+		positions := make(map[string]*eventPageCursor, len(leaves))
+		for _, leaf := range leaves {
+			name := leaf.GetName()
+			position := cursor.leaf(name, earliestEvent)
+			positions[name] = position
+
+			scope, err := e.clusters.leafScope(ctx, name)
+			if err != nil {
+				l.Warn("baton-teleport: skipping audit events of unreachable leaf cluster", zap.String("cluster", name), zap.Error(err))
+				continue
+			}
+
+			events, more, err := e.searchCluster(ctx, scope, position, to)
+			if err != nil {
+				l.Warn("baton-teleport: skipping audit events of leaf cluster", zap.String("cluster", name), zap.Error(err))
+				continue
+			}
+			result = append(result, events...)
+			hasMore = hasMore || more
+		}
+		cursor.Leaves = positions
+	}
+
+	marshalledCursor, err := cursor.marshal()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return result, &pagination.StreamState{
+		Cursor:  marshalledCursor,
+		HasMore: hasMore,
+	}, nil, nil
+}
+
+// searchCluster converts the next page of the audit log of the cluster of
+// scope, nil for the root cluster, and advances position past it. Every
+// event of a cluster's audit log comes from that cluster, so resource IDs
+// are namespaced by scope. position is left as it was when the page fails,
+// and the page is read again next time. It reports whether the cluster has
+// more events in the window ending at to.
+func (e *auditEventFeed) searchCluster(
+	ctx context.Context,
+	scope *clusterScope,
+	position *eventPageCursor,
+	to time.Time,
+) ([]*v2.Event, bool, error) {
+	l := ctxzap.Extract(ctx)
+
+	c, clusterName := e.client, ""
+	if scope != nil {
+		c, clusterName = scope.client, scope.name
+	}
+
+	from, err := time.Parse(time.RFC3339Nano, position.StartAt)
+	if err != nil {
+		return nil, false, fmt.Errorf("baton-teleport: failed to parse audit start_at: %w", err)
 	}
-	to := time.Now().UTC()
 
 	// Teleport supports EventOrderAscending directly — no need to reverse,
 	// unlike providers that only expose descending order.
-	auditEvents, lastKey, err := e.client.SearchEvents(
+	auditEvents, lastKey, err := c.SearchEvents(
 		ctx,
 		from,
 		to,
@@ -147,56 +232,52 @@ func (e *auditEventFeed) ListEvents(
 		resourceChangeEventTypes,
 		eventsPageSize,
 		types.EventOrderAscending,
-		cursor.LastKey,
+		position.LastKey,
 		"",
 	)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("baton-teleport: failed to search audit events: %w", err)
+		return nil, false, fmt.Errorf("baton-teleport: failed to search audit events: %w", err)
 	}
 
 	l.Debug("fetched audit events",
+		zap.String("cluster", clusterName),
 		zap.Int("count", len(auditEvents)),
 		zap.String("last_key", lastKey),
 	)
 
+	next := *position
 	var result []*v2.Event
 	for _, auditEvent := range auditEvents {
 		// Access request state-change events (review, update, expire) carry
 		// empty User and Roles fields. We must resolve the original request by
 		// ID before converting, so this case is handled here rather than in the
 		// pure convertAuditEvent function.
-		if derived, eventTime, ok, err := e.tryConvertAccessRequestStateChange(ctx, auditEvent); err != nil {
-			return nil, nil, nil, err
+		if derived, eventTime, ok, err := e.tryConvertAccessRequestStateChange(ctx, scope, auditEvent); err != nil {
+			return nil, false, err
 		} else if ok {
 			result = append(result, derived...)
-			cursor.updateLatestEvent(eventTime)
+			next.updateLatestEvent(eventTime)
 			continue
 		}
 
-		derived, eventTime := convertAuditEvent(auditEvent)
+		derived, eventTime := convertAuditEvent(scope, auditEvent)
 		result = append(result, derived...)
-		cursor.updateLatestEvent(eventTime)
+		next.updateLatestEvent(eventTime)
 	}
 
 	if lastKey == "" {
-		cursor.prepareNextSync()
+		next.prepareNextSync()
 	} else {
-		cursor.LastKey = lastKey
-	}
-
-	marshalledCursor, err := cursor.marshal()
-	if err != nil {
-		return nil, nil, nil, err
+		next.LastKey = lastKey
 	}
+	*position = next
 
-	return result, &pagination.StreamState{
-		Cursor:  marshalledCursor,
-		HasMore: lastKey != "",
-	}, nil, nil
+	return result, lastKey != "", nil
 }
 
 // convertAuditEvent maps a single Teleport AuditEvent to one or more baton
-// Events and returns the event timestamp for cursor tracking.
+// Events and returns the event timestamp for cursor tracking. Resource IDs
+// are namespaced by scope, the cluster the event comes from.
 //
 // A single Teleport event may produce multiple baton events: for example,
 // user.create emits a ResourceChangeEvent for the user AND a CreateGrantEvent
@@ -207,36 +288,50 @@ func (e *auditEventFeed) ListEvents(
 //     UpdateUser() → "user.update". Both carry the complete Roles list.
 //   - Role events also use SEPARATE types: "role.created" → *events.RoleCreate,
 //     "role.updated" (code T9002I) → *events.RoleUpdate.
-func convertAuditEvent(auditEvent events.AuditEvent) ([]*v2.Event, time.Time) {
+func convertAuditEvent(scope *clusterScope, auditEvent events.AuditEvent) ([]*v2.Event, time.Time) {
 	switch e := auditEvent.(type) {
 	// --- User create (fires "user.create" → *events.UserCreate):
 	//     emit a ResourceChangeEvent + a CreateGrantEvent per role.
 	//     The Roles slice is the complete post-operation role list.
 	case *events.UserCreate:
-		return convertUserEvent(e.GetID(), e.GetTime(), e.Name, e.Roles)
+		return convertUserEvent(scope, e.GetID(), e.GetTime(), e.Name, e.Roles)
 
 	// --- User update (fires "user.update" → *events.UserUpdate):
 	//     Teleport v18 fires a SEPARATE event type for user modifications
 	//     (verified against live API 2026-02-27). Same payload shape as
 	//     UserCreate — carries the complete new role list.
 	case *events.UserUpdate:
-		return convertUserEvent(e.GetID(), e.GetTime(), e.Name, e.Roles)
+		return convertUserEvent(scope, e.GetID(), e.GetTime(), e.Name, e.Roles)
 
 	// --- Role create (fires "role.created" → *events.RoleCreate):
 	case *events.RoleCreate:
-		return singleResourceChange(e.GetID(), e.GetTime(), roleResourceType.Id, e.Name)
+		return singleResourceChange(e.GetID(), e.GetTime(), roleResourceType.Id, scopedName(scope, e.Name))
 	// --- Role update (fires "role.updated" → *events.RoleUpdate):
 	//     Verified against live API 2026-02-27: role modifications fire a
 	//     separate "role.updated" event with code T9002I.
 	case *events.RoleUpdate:
-		return singleResourceChange(e.GetID(), e.GetTime(), roleResourceType.Id, e.Name)
+		return singleResourceChange(e.GetID(), e.GetTime(), roleResourceType.Id, scopedName(scope, e.Name))
 	// --- Locks ---
-	// Only user targets are handled: Get() surfaces IsLocked as STATUS_DISABLED/
-	// STATUS_ENABLED. Role targets are ignored (role Get() has no lock status).
+	// Only user targets are handled: Get() reads the user's locks in force and
+	// reports STATUS_DISABLED/STATUS_ENABLED. Role targets are ignored (role
+	// Get() has no lock status).
 	case *events.LockCreate:
-		return convertLockEvent(e.GetID(), e.GetTime(), e.Lock.Target)
+		return convertLockEvent(scope, e.GetID(), e.GetTime(), e.Lock.Target)
 	case *events.LockDelete:
-		return convertLockEvent(e.GetID(), e.GetTime(), e.Lock.Target)
+		return convertLockEvent(scope, e.GetID(), e.GetTime(), e.Lock.Target)
+	// --- Apps and databases (fire "app.create"/"app.update" and
+	//     "db.create"/"db.update"): the resource ID is the name.
+	case *events.AppCreate:
+		return singleResourceChange(e.GetID(), e.GetTime(), appResourceType.Id, scopedName(scope, e.Name))
+	case *events.AppUpdate:
+		return singleResourceChange(e.GetID(), e.GetTime(), appResourceType.Id, scopedName(scope, e.Name))
+	case *events.DatabaseCreate:
+		return singleResourceChange(e.GetID(), e.GetTime(), dbResourceType.Id, scopedName(scope, e.Name))
+	case *events.DatabaseUpdate:
+		return singleResourceChange(e.GetID(), e.GetTime(), dbResourceType.Id, scopedName(scope, e.Name))
+	// --- Nodes (fire "instance.join"): the resource ID is the host UUID.
+	case *events.InstanceJoin:
+		return convertInstanceJoinEvent(scope, e)
 	}
 
 	return nil, time.Time{}
@@ -265,17 +360,18 @@ func convertAuditEvent(auditEvent events.AuditEvent) ([]*v2.Event, time.Time) {
 // If a future Teleport release adds an authoritative "previous roles of the
 // target user" field to user.update events, we can compute the diff here
 // and emit CreateRevokeEvents for removed roles.
-func convertUserEvent(id string, t time.Time, userName string, roles []string) ([]*v2.Event, time.Time) {
+func convertUserEvent(scope *clusterScope, id string, t time.Time, userName string, roles []string) ([]*v2.Event, time.Time) {
 	if userName == "" {
 		return nil, time.Time{}
 	}
 	var out []*v2.Event
-	out = append(out, makeResourceChangeEvent(id, t, userResourceType.Id, userName))
+	out = append(out, makeResourceChangeEvent(id, t, userResourceType.Id, scope.resourceID(userName)))
 	for _, roleName := range roles {
 		if roleName == "" {
 			continue
 		}
 		out = append(out, makeCreateGrantEvent(
+			scope,
 			fmt.Sprintf("%s:role:%s", id, roleName),
 			t,
 			roleName,
@@ -285,6 +381,15 @@ func convertUserEvent(id string, t time.Time, userName string, roles []string) (
 	return out, t
 }
 
+// scopedName returns the resource ID of a named resource in scope, keeping
+// an empty name empty so the event is dropped.
+func scopedName(scope *clusterScope, name string) string {
+	if name == "" {
+		return ""
+	}
+	return scope.resourceID(name)
+}
+
 // singleResourceChange returns a one-element slice containing a
 // ResourceChangeEvent, or nil if the resource name is empty.
 func singleResourceChange(id string, t time.Time, resourceType, resourceName string) ([]*v2.Event, time.Time) {
@@ -311,12 +416,13 @@ func makeResourceChangeEvent(id string, t time.Time, resourceType, resourceName
 }
 
 // roleMembershipEntitlementAndPrincipal builds the shared entitlement and
-// principal used by both grant and revoke event constructors.
-func roleMembershipEntitlementAndPrincipal(roleName, userName string) (*v2.Entitlement, *v2.Resource) {
+// principal used by both grant and revoke event constructors. The role and
+// user are both in the cluster of scope.
+func roleMembershipEntitlementAndPrincipal(scope *clusterScope, roleName, userName string) (*v2.Entitlement, *v2.Resource) {
 	roleResource := &v2.Resource{
 		Id: &v2.ResourceId{
 			ResourceType: roleResourceType.Id,
-			Resource:     roleName,
+			Resource:     scope.resourceID(roleName),
 		},
 		DisplayName: roleName,
 	}
@@ -324,7 +430,7 @@ func roleMembershipEntitlementAndPrincipal(roleName, userName string) (*v2.Entit
 	return ent.NewAssignmentEntitlement(roleResource, roleMembership), &v2.Resource{
 		Id: &v2.ResourceId{
 			ResourceType: userResourceType.Id,
-			Resource:     userName,
+			Resource:     scope.resourceID(userName),
 		},
 		DisplayName: userName,
 	}
@@ -332,8 +438,8 @@ func roleMembershipEntitlementAndPrincipal(roleName, userName string) (*v2.Entit
 
 // makeCreateGrantEvent builds a v2.Event wrapping a CreateGrantEvent that
 // signals a role membership was assigned to a user.
-func makeCreateGrantEvent(id string, t time.Time, roleName, userName string) *v2.Event {
-	entitlement, principal := roleMembershipEntitlementAndPrincipal(roleName, userName)
+func makeCreateGrantEvent(scope *clusterScope, id string, t time.Time, roleName, userName string) *v2.Event {
+	entitlement, principal := roleMembershipEntitlementAndPrincipal(scope, roleName, userName)
 	return &v2.Event{
 		Id:         id,
 		OccurredAt: timestamppb.New(t),
@@ -348,8 +454,8 @@ func makeCreateGrantEvent(id string, t time.Time, roleName, userName string) *v2
 
 // makeCreateRevokeEvent builds a v2.Event wrapping a CreateRevokeEvent that
 // signals a role membership was removed from a user.
-func makeCreateRevokeEvent(id string, t time.Time, roleName, userName string) *v2.Event {
-	entitlement, principal := roleMembershipEntitlementAndPrincipal(roleName, userName)
+func makeCreateRevokeEvent(scope *clusterScope, id string, t time.Time, roleName, userName string) *v2.Event {
+	entitlement, principal := roleMembershipEntitlementAndPrincipal(scope, roleName, userName)
 	return &v2.Event{
 		Id:         id,
 		OccurredAt: timestamppb.New(t),
@@ -363,17 +469,29 @@ func makeCreateRevokeEvent(id string, t time.Time, roleName, userName string) *v
 }
 
 // convertLockEvent emits a ResourceChangeEvent for the user targeted by a
-// Teleport lock (create or delete). C1 calls Get() on the user, which
-// surfaces IsLocked as STATUS_DISABLED (locked) or STATUS_ENABLED (unlocked).
+// Teleport lock (create or delete). C1 calls Get() on the user, which lists
+// the locks in force targeting the user and reports STATUS_DISABLED (locked)
+// or STATUS_ENABLED (unlocked).
 //
 // Role targets are ignored: the role resource has no lock/status field, so
 // a ResourceChangeEvent would trigger a Get() that discovers nothing new.
 // Other targets (node, login, MFA device, etc.) are also ignored.
-func convertLockEvent(id string, t time.Time, target types.LockTarget) ([]*v2.Event, time.Time) {
+func convertLockEvent(scope *clusterScope, id string, t time.Time, target types.LockTarget) ([]*v2.Event, time.Time) {
 	if target.User == "" {
 		return nil, time.Time{}
 	}
-	return []*v2.Event{makeResourceChangeEvent(id, t, userResourceType.Id, target.User)}, t
+	return []*v2.Event{makeResourceChangeEvent(id, t, userResourceType.Id, scope.resourceID(target.User))}, t
+}
+
+// convertInstanceJoinEvent emits a ResourceChangeEvent for the node that
+// joined the cluster. Modern agents join as Instance whatever services they
+// run; older ones join as Node. Failed joins and agents joining with another
+// system role (app, db, kube agents...) are ignored.
+func convertInstanceJoinEvent(scope *clusterScope, e *events.InstanceJoin) ([]*v2.Event, time.Time) {
+	if !e.Success || (e.Role != types.RoleNode.String() && e.Role != types.RoleInstance.String()) {
+		return nil, time.Time{}
+	}
+	return singleResourceChange(e.GetID(), e.GetTime(), nodeResourceType.Id, scopedName(scope, e.HostID))
 }
 
 // tryConvertAccessRequestStateChange handles access request events where the
@@ -394,8 +512,10 @@ func convertLockEvent(id string, t time.Time, target types.LockTarget) ([]*v2.Ev
 // Returns (events, time, true, nil) if the event was handled, or
 // (nil, zero, false, nil) if it should fall through to convertAuditEvent.
 // Returns a non-nil error when the API lookup fails so the caller can retry.
+// Requests of a leaf cluster are looked up in that cluster.
 func (e *auditEventFeed) tryConvertAccessRequestStateChange(
 	ctx context.Context,
+	scope *clusterScope,
 	auditEvent events.AuditEvent,
 ) ([]*v2.Event, time.Time, bool, error) {
 	arc, ok := auditEvent.(*events.AccessRequestCreate)
@@ -419,7 +539,11 @@ func (e *auditEventFeed) tryConvertAccessRequestStateChange(
 
 	l := ctxzap.Extract(ctx)
 
-	if e.client == nil {
+	c := e.client
+	if scope != nil {
+		c = scope.client
+	}
+	if c == nil {
 		l.Debug("cannot resolve access request state change: no client available",
 			zap.String("request_id", arc.RequestID),
 		)
@@ -427,7 +551,7 @@ func (e *auditEventFeed) tryConvertAccessRequestStateChange(
 	}
 
 	// Look up the original access request to recover user and roles.
-	requests, err := e.client.GetAccessRequests(ctx, types.AccessRequestFilter{
+	requests, err := c.GetAccessRequests(ctx, types.AccessRequestFilter{
 		ID: arc.RequestID,
 	})
 	if err != nil {
@@ -458,6 +582,7 @@ func (e *auditEventFeed) tryConvertAccessRequestStateChange(
 				continue
 			}
 			out = append(out, makeCreateGrantEvent(
+				scope,
 				fmt.Sprintf("%s:role:%s", arc.GetID(), roleName),
 				t,
 				roleName,
@@ -470,6 +595,7 @@ func (e *auditEventFeed) tryConvertAccessRequestStateChange(
 				continue
 			}
 			out = append(out, makeCreateRevokeEvent(
+				scope,
 				fmt.Sprintf("%s:role:%s", arc.GetID(), roleName),
 				t,
 				roleName,
@@ -491,6 +617,6 @@ func (e *auditEventFeed) tryConvertAccessRequestStateChange(
 	return out, t, true, nil
 }
 
-func newAuditEventFeed(c *client.TeleportClient) *auditEventFeed {
-	return &auditEventFeed{client: c}
+func newAuditEventFeed(c *client.TeleportClient, leafClusters bool) *auditEventFeed {
+	return &auditEventFeed{client: c, clusters: newClusterSet(c, leafClusters)}
 }
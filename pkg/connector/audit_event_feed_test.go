@@ -34,7 +34,7 @@ func TestConvertAuditEvent_UserCreate_NoRoles(t *testing.T) {
 		Metadata:         events.Metadata{ID: "uc-1", Time: eventTime},
 		ResourceMetadata: events.ResourceMetadata{Name: "alice"},
 	}
-	evts, ts := convertAuditEvent(e)
+	evts, ts := convertAuditEvent(nil, e)
 	requireSingleResourceChange(t, evts, eventTime, userResourceType.Id, "alice")
 	require.Equal(t, eventTime.Unix(), ts.Unix())
 }
@@ -46,7 +46,7 @@ func TestConvertAuditEvent_UserCreate_WithRoles(t *testing.T) {
 		ResourceMetadata: events.ResourceMetadata{Name: "bob"},
 		Roles:            []string{"reviewer", "access"},
 	}
-	evts, ts := convertAuditEvent(e)
+	evts, ts := convertAuditEvent(nil, e)
 	// 1 ResourceChangeEvent + 2 CreateGrantEvents
 	require.Len(t, evts, 3)
 	require.Equal(t, eventTime.Unix(), ts.Unix())
@@ -74,12 +74,33 @@ func TestConvertAuditEvent_UserCreate_WithRoles(t *testing.T) {
 	require.True(t, roleNames["access"])
 }
 
+func TestConvertAuditEvent_UserCreate_LeafCluster(t *testing.T) {
+	eventTime := time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC)
+	e := &events.UserCreate{
+		Metadata:         events.Metadata{ID: "uc-leaf", Time: eventTime, ClusterName: "leaf.example.com"},
+		ResourceMetadata: events.ResourceMetadata{Name: "bob"},
+		Roles:            []string{"access"},
+	}
+	evts, _ := convertAuditEvent(&clusterScope{name: "leaf.example.com", leaf: true}, e)
+	require.Len(t, evts, 2)
+
+	rc := evts[0].GetResourceChangeEvent()
+	require.NotNil(t, rc)
+	require.Equal(t, "leaf.example.com/bob", rc.ResourceId.Resource)
+
+	cg := evts[1].GetCreateGrantEvent()
+	require.NotNil(t, cg)
+	require.Equal(t, "role:leaf.example.com/access:member", cg.Entitlement.Id)
+	require.Equal(t, "leaf.example.com/bob", cg.Principal.Id.Resource)
+	require.Equal(t, "bob", cg.Principal.DisplayName)
+}
+
 func TestConvertAuditEvent_UserCreate_EmptyName(t *testing.T) {
 	e := &events.UserCreate{
 		Metadata:         events.Metadata{ID: "uc-empty"},
 		ResourceMetadata: events.ResourceMetadata{Name: ""},
 	}
-	evts, ts := convertAuditEvent(e)
+	evts, ts := convertAuditEvent(nil, e)
 	require.Nil(t, evts)
 	require.True(t, ts.IsZero())
 }
@@ -90,7 +111,7 @@ func TestConvertAuditEvent_UserCreate_SkipsEmptyRoleName(t *testing.T) {
 		ResourceMetadata: events.ResourceMetadata{Name: "carol"},
 		Roles:            []string{"", "access", ""},
 	}
-	evts, _ := convertAuditEvent(e)
+	evts, _ := convertAuditEvent(nil, e)
 	// 1 ResourceChangeEvent + 1 CreateGrantEvent (empty roles skipped)
 	require.Len(t, evts, 2)
 	require.NotNil(t, evts[0].GetResourceChangeEvent())
@@ -110,7 +131,7 @@ func TestConvertAuditEvent_UserUpdate_WithRoles(t *testing.T) {
 		ResourceMetadata: events.ResourceMetadata{Name: "dave"},
 		Roles:            []string{"reviewer", "auditor"},
 	}
-	evts, ts := convertAuditEvent(e)
+	evts, ts := convertAuditEvent(nil, e)
 	// 1 ResourceChangeEvent + 2 CreateGrantEvents (one per role).
 	require.Len(t, evts, 3)
 	require.Equal(t, eventTime.Unix(), ts.Unix())
@@ -136,7 +157,7 @@ func TestConvertAuditEvent_UserUpdate_NoRoles(t *testing.T) {
 		Metadata:         events.Metadata{ID: "uu-noroles", Time: eventTime},
 		ResourceMetadata: events.ResourceMetadata{Name: "dave"},
 	}
-	evts, ts := convertAuditEvent(e)
+	evts, ts := convertAuditEvent(nil, e)
 	requireSingleResourceChange(t, evts, eventTime, userResourceType.Id, "dave")
 	require.Equal(t, eventTime.Unix(), ts.Unix())
 }
@@ -146,7 +167,7 @@ func TestConvertAuditEvent_UserUpdate_EmptyName(t *testing.T) {
 		Metadata:         events.Metadata{ID: "uu-empty"},
 		ResourceMetadata: events.ResourceMetadata{Name: ""},
 	}
-	evts, ts := convertAuditEvent(e)
+	evts, ts := convertAuditEvent(nil, e)
 	require.Nil(t, evts)
 	require.True(t, ts.IsZero())
 }
@@ -157,7 +178,7 @@ func TestConvertAuditEvent_UserUpdate_SkipsEmptyRoleName(t *testing.T) {
 		ResourceMetadata: events.ResourceMetadata{Name: "dave"},
 		Roles:            []string{"", "access", ""},
 	}
-	evts, _ := convertAuditEvent(e)
+	evts, _ := convertAuditEvent(nil, e)
 	// 1 ResourceChangeEvent + 1 CreateGrantEvent (empty roles skipped)
 	require.Len(t, evts, 2)
 	require.NotNil(t, evts[0].GetResourceChangeEvent())
@@ -172,7 +193,7 @@ func TestConvertAuditEvent_UserDelete_IsNotHandled(t *testing.T) {
 		Metadata:         events.Metadata{ID: "ud-1"},
 		ResourceMetadata: events.ResourceMetadata{Name: "eve"},
 	}
-	evts, ts := convertAuditEvent(e)
+	evts, ts := convertAuditEvent(nil, e)
 	// Delete events are intentionally not handled: ResourceChangeEvent would
 	// trigger Get() which returns "not found" for deleted resources.
 	require.Nil(t, evts)
@@ -186,7 +207,7 @@ func TestConvertAuditEvent_RoleCreate(t *testing.T) {
 		Metadata:         events.Metadata{ID: "rc-1"},
 		ResourceMetadata: events.ResourceMetadata{Name: "reviewer"},
 	}
-	evts, _ := convertAuditEvent(e)
+	evts, _ := convertAuditEvent(nil, e)
 	requireSingleResourceChange(t, evts, time.Time{}, roleResourceType.Id, "reviewer")
 }
 
@@ -197,7 +218,7 @@ func TestConvertAuditEvent_RoleUpdate(t *testing.T) {
 		Metadata:         events.Metadata{ID: "ru-1"},
 		ResourceMetadata: events.ResourceMetadata{Name: "editor"},
 	}
-	evts, _ := convertAuditEvent(e)
+	evts, _ := convertAuditEvent(nil, e)
 	requireSingleResourceChange(t, evts, time.Time{}, roleResourceType.Id, "editor")
 }
 
@@ -206,31 +227,33 @@ func TestConvertAuditEvent_RoleDelete_IsNotHandled(t *testing.T) {
 		Metadata:         events.Metadata{ID: "rd-1"},
 		ResourceMetadata: events.ResourceMetadata{Name: "old-role"},
 	}
-	evts, ts := convertAuditEvent(e)
+	evts, ts := convertAuditEvent(nil, e)
 	require.Nil(t, evts)
 	require.True(t, ts.IsZero())
 }
 
 // --- App CRUD ---
 
-func TestConvertAuditEvent_AppCreate_IsNotHandled(t *testing.T) {
+func TestConvertAuditEvent_AppCreate(t *testing.T) {
+	eventTime := time.Date(2024, 5, 10, 10, 0, 0, 0, time.UTC)
 	e := &events.AppCreate{
-		Metadata:         events.Metadata{ID: "ac-1"},
+		Metadata:         events.Metadata{ID: "ac-1", Time: eventTime},
 		ResourceMetadata: events.ResourceMetadata{Name: "my-app"},
 	}
-	evts, ts := convertAuditEvent(e)
-	require.Nil(t, evts)
-	require.True(t, ts.IsZero())
+	evts, ts := convertAuditEvent(nil, e)
+	requireSingleResourceChange(t, evts, eventTime, appResourceType.Id, "my-app")
+	require.Equal(t, eventTime.Unix(), ts.Unix())
 }
 
-func TestConvertAuditEvent_AppUpdate_IsNotHandled(t *testing.T) {
+func TestConvertAuditEvent_AppUpdate(t *testing.T) {
+	eventTime := time.Date(2024, 5, 10, 10, 0, 0, 0, time.UTC)
 	e := &events.AppUpdate{
-		Metadata:         events.Metadata{ID: "au-1"},
+		Metadata:         events.Metadata{ID: "au-1", Time: eventTime},
 		ResourceMetadata: events.ResourceMetadata{Name: "my-app"},
 	}
-	evts, ts := convertAuditEvent(e)
-	require.Nil(t, evts)
-	require.True(t, ts.IsZero())
+	evts, ts := convertAuditEvent(nil, e)
+	requireSingleResourceChange(t, evts, eventTime, appResourceType.Id, "my-app")
+	require.Equal(t, eventTime.Unix(), ts.Unix())
 }
 
 func TestConvertAuditEvent_AppDelete_IsNotHandled(t *testing.T) {
@@ -238,31 +261,33 @@ func TestConvertAuditEvent_AppDelete_IsNotHandled(t *testing.T) {
 		Metadata:         events.Metadata{ID: "ad-1"},
 		ResourceMetadata: events.ResourceMetadata{Name: "old-app"},
 	}
-	evts, ts := convertAuditEvent(e)
+	evts, ts := convertAuditEvent(nil, e)
 	require.Nil(t, evts)
 	require.True(t, ts.IsZero())
 }
 
 // --- Database CRUD ---
 
-func TestConvertAuditEvent_DatabaseCreate_IsNotHandled(t *testing.T) {
+func TestConvertAuditEvent_DatabaseCreate(t *testing.T) {
+	eventTime := time.Date(2024, 5, 10, 10, 0, 0, 0, time.UTC)
 	e := &events.DatabaseCreate{
-		Metadata:         events.Metadata{ID: "dc-1"},
+		Metadata:         events.Metadata{ID: "dc-1", Time: eventTime},
 		ResourceMetadata: events.ResourceMetadata{Name: "prod-db"},
 	}
-	evts, ts := convertAuditEvent(e)
-	require.Nil(t, evts)
-	require.True(t, ts.IsZero())
+	evts, ts := convertAuditEvent(nil, e)
+	requireSingleResourceChange(t, evts, eventTime, dbResourceType.Id, "prod-db")
+	require.Equal(t, eventTime.Unix(), ts.Unix())
 }
 
-func TestConvertAuditEvent_DatabaseUpdate_IsNotHandled(t *testing.T) {
+func TestConvertAuditEvent_DatabaseUpdate(t *testing.T) {
+	eventTime := time.Date(2024, 5, 10, 10, 0, 0, 0, time.UTC)
 	e := &events.DatabaseUpdate{
-		Metadata:         events.Metadata{ID: "du-1"},
+		Metadata:         events.Metadata{ID: "du-1", Time: eventTime},
 		ResourceMetadata: events.ResourceMetadata{Name: "prod-db"},
 	}
-	evts, ts := convertAuditEvent(e)
-	require.Nil(t, evts)
-	require.True(t, ts.IsZero())
+	evts, ts := convertAuditEvent(nil, e)
+	requireSingleResourceChange(t, evts, eventTime, dbResourceType.Id, "prod-db")
+	require.Equal(t, eventTime.Unix(), ts.Unix())
 }
 
 func TestConvertAuditEvent_DatabaseDelete_IsNotHandled(t *testing.T) {
@@ -270,7 +295,64 @@ func TestConvertAuditEvent_DatabaseDelete_IsNotHandled(t *testing.T) {
 		Metadata:         events.Metadata{ID: "dd-1"},
 		ResourceMetadata: events.ResourceMetadata{Name: "old-db"},
 	}
-	evts, ts := convertAuditEvent(e)
+	evts, ts := convertAuditEvent(nil, e)
+	require.Nil(t, evts)
+	require.True(t, ts.IsZero())
+}
+
+func TestConvertAuditEvent_AppCreate_EmptyName(t *testing.T) {
+	e := &events.AppCreate{Metadata: events.Metadata{ID: "ac-empty"}}
+	evts, ts := convertAuditEvent(nil, e)
+	require.Nil(t, evts)
+	require.True(t, ts.IsZero())
+}
+
+// --- Node join ---
+
+func TestConvertAuditEvent_InstanceJoin_Node(t *testing.T) {
+	eventTime := time.Date(2024, 5, 11, 10, 0, 0, 0, time.UTC)
+	e := &events.InstanceJoin{
+		Metadata: events.Metadata{ID: "ij-1", Time: eventTime},
+		Status:   events.Status{Success: true},
+		HostID:   "5d2f4a9e-node-uuid",
+		NodeName: "db-01",
+		Role:     "Node",
+	}
+	evts, _ := convertAuditEvent(nil, e)
+	requireSingleResourceChange(t, evts, eventTime, nodeResourceType.Id, "5d2f4a9e-node-uuid")
+}
+
+func TestConvertAuditEvent_InstanceJoin_Instance(t *testing.T) {
+	eventTime := time.Date(2024, 5, 11, 10, 0, 0, 0, time.UTC)
+	e := &events.InstanceJoin{
+		Metadata: events.Metadata{ID: "ij-4", Time: eventTime},
+		Status:   events.Status{Success: true},
+		HostID:   "7c1e3b2a-instance-uuid",
+		Role:     "Instance",
+	}
+	evts, _ := convertAuditEvent(nil, e)
+	requireSingleResourceChange(t, evts, eventTime, nodeResourceType.Id, "7c1e3b2a-instance-uuid")
+}
+
+func TestConvertAuditEvent_InstanceJoin_OtherRole_Ignored(t *testing.T) {
+	e := &events.InstanceJoin{
+		Metadata: events.Metadata{ID: "ij-2"},
+		Status:   events.Status{Success: true},
+		HostID:   "app-agent-uuid",
+		Role:     "App",
+	}
+	evts, ts := convertAuditEvent(nil, e)
+	require.Nil(t, evts)
+	require.True(t, ts.IsZero())
+}
+
+func TestConvertAuditEvent_InstanceJoin_Failed_Ignored(t *testing.T) {
+	e := &events.InstanceJoin{
+		Metadata: events.Metadata{ID: "ij-3"},
+		HostID:   "node-uuid",
+		Role:     "Node",
+	}
+	evts, ts := convertAuditEvent(nil, e)
 	require.Nil(t, evts)
 	require.True(t, ts.IsZero())
 }
@@ -278,7 +360,7 @@ func TestConvertAuditEvent_DatabaseDelete_IsNotHandled(t *testing.T) {
 // --- Unknown / empty ---
 
 func TestConvertAuditEvent_UnknownType(t *testing.T) {
-	evts, ts := convertAuditEvent(&events.SessionStart{})
+	evts, ts := convertAuditEvent(nil, &events.SessionStart{})
 	require.Nil(t, evts)
 	require.True(t, ts.IsZero())
 }
@@ -291,7 +373,7 @@ func TestConvertAuditEvent_DerivedEventIDsAreUnique(t *testing.T) {
 		ResourceMetadata: events.ResourceMetadata{Name: "frank"},
 		Roles:            []string{"r1", "r2", "r3"},
 	}
-	evts, _ := convertAuditEvent(e)
+	evts, _ := convertAuditEvent(nil, e)
 	require.Len(t, evts, 4)
 
 	seen := make(map[string]bool)
@@ -309,7 +391,7 @@ func TestConvertAuditEvent_GrantEntitlementIDMatchesFullSync(t *testing.T) {
 		ResourceMetadata: events.ResourceMetadata{Name: "grace"},
 		Roles:            []string{"reviewer"},
 	}
-	evts, _ := convertAuditEvent(e)
+	evts, _ := convertAuditEvent(nil, e)
 	require.Len(t, evts, 2)
 
 	grantEvt := evts[1].GetCreateGrantEvent()
@@ -344,7 +426,7 @@ func TestConvertAuditEvent_AccessRequestCreate_NotHandled(t *testing.T) {
 		Roles:        []string{"reviewer"},
 		RequestState: "APPROVED",
 	}
-	evts, ts := convertAuditEvent(e)
+	evts, ts := convertAuditEvent(nil, e)
 	require.Nil(t, evts)
 	require.True(t, ts.IsZero())
 }
@@ -366,12 +448,12 @@ func TestConvertAuditEvent_AccessRequestCreate_NotHandled(t *testing.T) {
 // with empty User/Roles fields.
 
 func TestTryConvertAccessRequestStateChange_NonAccessRequestEvent(t *testing.T) {
-	feed := newAuditEventFeed(nil)
+	feed := newAuditEventFeed(nil, false)
 	e := &events.UserCreate{
 		Metadata:         events.Metadata{ID: "uc-1"},
 		ResourceMetadata: events.ResourceMetadata{Name: "alice"},
 	}
-	_, _, ok, err := feed.tryConvertAccessRequestStateChange(context.Background(), e)
+	_, _, ok, err := feed.tryConvertAccessRequestStateChange(context.Background(), nil, e)
 	require.NoError(t, err)
 	require.False(t, ok, "non-AccessRequestCreate event should return ok=false")
 }
@@ -379,14 +461,14 @@ func TestTryConvertAccessRequestStateChange_NonAccessRequestEvent(t *testing.T)
 func TestTryConvertAccessRequestStateChange_SubmissionNotIntercepted(t *testing.T) {
 	// Submission events (User is populated) should not be intercepted —
 	// they fall through to convertAuditEvent.
-	feed := newAuditEventFeed(nil)
+	feed := newAuditEventFeed(nil, false)
 	e := &events.AccessRequestCreate{
 		Metadata:     events.Metadata{ID: "ar-submit"},
 		UserMetadata: events.UserMetadata{User: "alice"},
 		Roles:        []string{"editor"},
 		RequestState: "PENDING",
 	}
-	_, _, ok, err := feed.tryConvertAccessRequestStateChange(context.Background(), e)
+	_, _, ok, err := feed.tryConvertAccessRequestStateChange(context.Background(), nil, e)
 	require.NoError(t, err)
 	require.False(t, ok, "submission event (User populated) should return ok=false")
 }
@@ -395,14 +477,14 @@ func TestTryConvertAccessRequestStateChange_EmptyRequestID(t *testing.T) {
 	// State-change event with no RequestID — handled (true) but nothing emitted.
 	// The event time should still be returned so the cursor advances.
 	eventTime := time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)
-	feed := newAuditEventFeed(nil)
+	feed := newAuditEventFeed(nil, false)
 	e := &events.AccessRequestCreate{
 		Metadata:     events.Metadata{ID: "ar-no-id", Time: eventTime},
 		UserMetadata: events.UserMetadata{User: ""},
 		RequestState: "APPROVED",
 		RequestID:    "",
 	}
-	evts, t2, ok, err := feed.tryConvertAccessRequestStateChange(context.Background(), e)
+	evts, t2, ok, err := feed.tryConvertAccessRequestStateChange(context.Background(), nil, e)
 	require.NoError(t, err)
 	require.True(t, ok, "state-change event should return ok=true (handled)")
 	require.Nil(t, evts, "no events emitted without RequestID")
@@ -412,7 +494,7 @@ func TestTryConvertAccessRequestStateChange_EmptyRequestID(t *testing.T) {
 func TestTryConvertAccessRequestStateChange_NilClientGracefulFailure(t *testing.T) {
 	// State-change event with RequestID but nil client — handled gracefully,
 	// no events emitted (logs a debug message in production).
-	feed := newAuditEventFeed(nil)
+	feed := newAuditEventFeed(nil, false)
 	e := &events.AccessRequestCreate{
 		Metadata:     events.Metadata{ID: "ar-nil-client", Time: time.Now()},
 		UserMetadata: events.UserMetadata{User: ""},
@@ -420,7 +502,7 @@ func TestTryConvertAccessRequestStateChange_NilClientGracefulFailure(t *testing.
 		RequestID:    "some-request-uuid",
 	}
 	ctx := context.Background()
-	evts, _, ok, err := feed.tryConvertAccessRequestStateChange(ctx, e)
+	evts, _, ok, err := feed.tryConvertAccessRequestStateChange(ctx, nil, e)
 	require.NoError(t, err)
 	require.True(t, ok, "state-change event should be handled")
 	require.Nil(t, evts, "no events when client is nil")
@@ -430,7 +512,7 @@ func TestTryConvertAccessRequestStateChange_UpdateEvent(t *testing.T) {
 	// "access_request.update" fires when the request state transitions.
 	// Same Go type as review — empty User, requires lookup.
 	// Nil client → no API call → no events, but no error.
-	feed := newAuditEventFeed(nil)
+	feed := newAuditEventFeed(nil, false)
 	e := &events.AccessRequestCreate{
 		Metadata:     events.Metadata{ID: "ar-update", Time: time.Now()},
 		UserMetadata: events.UserMetadata{User: ""},
@@ -438,7 +520,7 @@ func TestTryConvertAccessRequestStateChange_UpdateEvent(t *testing.T) {
 		RequestID:    "update-request-uuid",
 	}
 	ctx := context.Background()
-	evts, _, ok, err := feed.tryConvertAccessRequestStateChange(ctx, e)
+	evts, _, ok, err := feed.tryConvertAccessRequestStateChange(ctx, nil, e)
 	require.NoError(t, err)
 	require.True(t, ok, "update event should be handled")
 	require.Nil(t, evts, "no events when client is nil")
@@ -448,7 +530,7 @@ func TestTryConvertAccessRequestStateChange_ExpireEvent(t *testing.T) {
 	// "access_request.expire" fires when a time-limited request expires.
 	// Same Go type — empty User, minimal payload, requires lookup.
 	// Nil client → no API call → no events, but no error.
-	feed := newAuditEventFeed(nil)
+	feed := newAuditEventFeed(nil, false)
 	e := &events.AccessRequestCreate{
 		Metadata:     events.Metadata{ID: "ar-expire", Time: time.Now()},
 		UserMetadata: events.UserMetadata{User: ""},
@@ -456,7 +538,7 @@ func TestTryConvertAccessRequestStateChange_ExpireEvent(t *testing.T) {
 		RequestID:    "expire-request-uuid",
 	}
 	ctx := context.Background()
-	evts, _, ok, err := feed.tryConvertAccessRequestStateChange(ctx, e)
+	evts, _, ok, err := feed.tryConvertAccessRequestStateChange(ctx, nil, e)
 	require.NoError(t, err)
 	require.True(t, ok, "expire event should be handled")
 	require.Nil(t, evts, "no events when client is nil")
@@ -467,7 +549,7 @@ func TestConvertAuditEvent_AccessRequestDelete_IsNotHandled(t *testing.T) {
 		Metadata:     events.Metadata{ID: "ard-1"},
 		UserMetadata: events.UserMetadata{User: "carol"},
 	}
-	evts, ts := convertAuditEvent(e)
+	evts, ts := convertAuditEvent(nil, e)
 	// Delete events are intentionally not handled.
 	require.Nil(t, evts)
 	require.True(t, ts.IsZero())
@@ -483,7 +565,7 @@ func TestConvertAuditEvent_LockCreate_UserTarget(t *testing.T) {
 			Target: types.LockTarget{User: "dave"},
 		},
 	}
-	evts, ts := convertAuditEvent(e)
+	evts, ts := convertAuditEvent(nil, e)
 	requireSingleResourceChange(t, evts, eventTime, userResourceType.Id, "dave")
 	require.Equal(t, eventTime.Unix(), ts.Unix())
 }
@@ -496,7 +578,7 @@ func TestConvertAuditEvent_LockCreate_RoleTarget_Ignored(t *testing.T) {
 			Target: types.LockTarget{Role: "auditor"},
 		},
 	}
-	evts, ts := convertAuditEvent(e)
+	evts, ts := convertAuditEvent(nil, e)
 	require.Nil(t, evts)
 	require.True(t, ts.IsZero())
 }
@@ -510,7 +592,7 @@ func TestConvertAuditEvent_LockCreate_UserAndRoleTarget(t *testing.T) {
 			Target: types.LockTarget{User: "eve", Role: "reviewer"},
 		},
 	}
-	evts, ts := convertAuditEvent(e)
+	evts, ts := convertAuditEvent(nil, e)
 	requireSingleResourceChange(t, evts, eventTime, userResourceType.Id, "eve")
 	require.Equal(t, eventTime.Unix(), ts.Unix())
 }
@@ -523,7 +605,7 @@ func TestConvertAuditEvent_LockCreate_UnknownTarget(t *testing.T) {
 			Target: types.LockTarget{Login: "root"},
 		},
 	}
-	evts, ts := convertAuditEvent(e)
+	evts, ts := convertAuditEvent(nil, e)
 	require.Nil(t, evts)
 	require.True(t, ts.IsZero())
 }
@@ -531,7 +613,7 @@ func TestConvertAuditEvent_LockCreate_UnknownTarget(t *testing.T) {
 // --- Lock delete (user re-enabled) ---
 
 func TestConvertAuditEvent_LockDelete_UserTarget(t *testing.T) {
-	// lock.deleted removes the lock → user flips from IsLocked=true to false.
+	// lock.deleted removes the lock → the user has no lock in force and is enabled again.
 	// Get() returns STATUS_ENABLED.
 	eventTime := time.Date(2024, 6, 7, 10, 0, 0, 0, time.UTC)
 	e := &events.LockDelete{
@@ -540,7 +622,7 @@ func TestConvertAuditEvent_LockDelete_UserTarget(t *testing.T) {
 			Target: types.LockTarget{User: "frank"},
 		},
 	}
-	evts, ts := convertAuditEvent(e)
+	evts, ts := convertAuditEvent(nil, e)
 	requireSingleResourceChange(t, evts, eventTime, userResourceType.Id, "frank")
 	require.Equal(t, eventTime.Unix(), ts.Unix())
 }
@@ -552,7 +634,7 @@ func TestConvertAuditEvent_LockDelete_RoleTarget_Ignored(t *testing.T) {
 			Target: types.LockTarget{Role: "editor"},
 		},
 	}
-	evts, ts := convertAuditEvent(e)
+	evts, ts := convertAuditEvent(nil, e)
 	require.Nil(t, evts)
 	require.True(t, ts.IsZero())
 }
@@ -560,7 +642,7 @@ func TestConvertAuditEvent_LockDelete_RoleTarget_Ignored(t *testing.T) {
 // --- Metadata ---
 
 func TestAuditEventFeedMetadata(t *testing.T) {
-	feed := newAuditEventFeed(nil)
+	feed := newAuditEventFeed(nil, false)
 	meta := feed.EventFeedMetadata(context.Background())
 	require.Equal(t, auditEventFeedID, meta.Id)
 	require.Len(t, meta.SupportedEventTypes, 3)
@@ -0,0 +1,266 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	machineidv1 "github.com/gravitational/teleport/api/gen/proto/go/teleport/machineid/v1"
+	"github.com/gravitational/teleport/api/types"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/conductorone/baton-teleport/pkg/client"
+)
+
+// botRolesPath is the update mask path of the roles a bot can assume.
+const botRolesPath = "spec.roles"
+
+type botBuilder struct {
+	resourceType *v2.ResourceType
+	client       *client.TeleportClient
+
+	// mu guards state, which is loaded once per sync and shared by every page
+	// of bots.
+	mu    sync.Mutex
+	state *botState
+}
+
+// botState holds the instances of every bot, keyed by bot name, and the
+// locks in force, so that listing bots does not cost an RPC per bot.
+type botState struct {
+	instances map[string][]*machineidv1.BotInstance
+	locks     []types.Lock
+}
+
+// locked reports whether a lock in force targets the bot's user or role,
+// which stops the bot from getting new certificates.
+func (s *botState) locked(bot *machineidv1.Bot) bool {
+	for _, lock := range s.locks {
+		target := lock.Target()
+		if (target.User != "" && target.User == bot.GetStatus().GetUserName()) ||
+			(target.Role != "" && target.Role == bot.GetStatus().GetRoleName()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *botBuilder) ResourceType(_ context.Context) *v2.ResourceType {
+	return b.resourceType
+}
+
+// Create a new connector resource for a Teleport Machine ID bot. Bots are
+// service accounts; the roles they hold are synced as grants on the role
+// resources. A locked bot is reported as disabled.
+func getBotResource(bot *machineidv1.Bot, instances []*machineidv1.BotInstance, locked bool) (*v2.Resource, error) {
+	name := bot.GetMetadata().GetName()
+
+	traits := make(map[string]interface{}, len(bot.GetSpec().GetTraits()))
+	for _, trait := range bot.GetSpec().GetTraits() {
+		traits[trait.GetName()] = stringsToInterfaces(trait.GetValues())
+	}
+
+	instanceIDs := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		instanceIDs = append(instanceIDs, instance.GetSpec().GetInstanceId())
+	}
+
+	profile := map[string]interface{}{
+		"bot_name":       name,
+		"user_name":      bot.GetStatus().GetUserName(),
+		"role_name":      bot.GetStatus().GetRoleName(),
+		"roles":          stringsToInterfaces(bot.GetSpec().GetRoles()),
+		"traits":         traits,
+		"instance_count": len(instances),
+		"instance_ids":   stringsToInterfaces(instanceIDs),
+		"last_heartbeat": formatTime(lastBotHeartbeat(instances)),
+	}
+	if ttl := bot.GetSpec().GetMaxSessionTtl(); ttl != nil {
+		profile["max_session_ttl"] = ttl.AsDuration().String()
+	}
+
+	status := v2.UserTrait_Status_STATUS_ENABLED
+	if locked {
+		status = v2.UserTrait_Status_STATUS_DISABLED
+	}
+
+	return rs.NewUserResource(
+		name,
+		botResourceType,
+		name,
+		[]rs.UserTraitOption{
+			rs.WithUserProfile(profile),
+			rs.WithUserLogin(bot.GetStatus().GetUserName()),
+			rs.WithStatus(status),
+			rs.WithAccountType(v2.UserTrait_ACCOUNT_TYPE_SERVICE),
+		},
+	)
+}
+
+// lastBotHeartbeat returns the most recent heartbeat recorded by any of the
+// bot's instances, or the zero time if none has reported yet.
+func lastBotHeartbeat(instances []*machineidv1.BotInstance) time.Time {
+	var last time.Time
+	for _, instance := range instances {
+		status := instance.GetStatus()
+		heartbeats := append([]*machineidv1.BotInstanceStatusHeartbeat{status.GetInitialHeartbeat()}, status.GetLatestHeartbeats()...)
+		for _, heartbeat := range heartbeats {
+			if heartbeat == nil || heartbeat.GetRecordedAt() == nil {
+				continue
+			}
+			if recordedAt := heartbeat.GetRecordedAt().AsTime(); recordedAt.After(last) {
+				last = recordedAt
+			}
+		}
+	}
+	return last
+}
+
+// List returns all the Machine ID bots from Teleport as resource objects,
+// together with the instances currently joined as each bot.
+func (b *botBuilder) List(ctx context.Context, _ *v2.ResourceId, opts rs.SyncOpAttrs) ([]*v2.Resource, *rs.SyncOpResults, error) {
+	var rv []*v2.Resource
+	if opts.PageToken.Token == "" {
+		// clear the cache
+		b.mu.Lock()
+		b.state = nil
+		b.mu.Unlock()
+	}
+
+	resp, err := b.client.GetBotsPage(ctx, &pagination.Token{Token: opts.PageToken.Token, Size: opts.PageToken.Size})
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to list bots: %w", err)
+	}
+
+	state, err := b.getBotState(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, bot := range resp.GetBots() {
+		rr, err := getBotResource(bot, state.instances[bot.GetMetadata().GetName()], state.locked(bot))
+		if err != nil {
+			return nil, nil, fmt.Errorf("baton-teleport: failed to create bot resource: %w", err)
+		}
+		rv = append(rv, rr)
+	}
+
+	return rv, &rs.SyncOpResults{NextPageToken: resp.GetNextPageToken()}, nil
+}
+
+func (b *botBuilder) Get(ctx context.Context, resourceId *v2.ResourceId, _ *v2.ResourceId) (*v2.Resource, annotations.Annotations, error) {
+	if resourceId == nil {
+		return nil, nil, fmt.Errorf("baton-teleport: resourceId is required")
+	}
+
+	bot, err := b.client.BotServiceClient().GetBot(ctx, &machineidv1.GetBotRequest{BotName: resourceId.Resource})
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to get bot %s: %w", resourceId.Resource, err)
+	}
+
+	// The audit feed calls Get after a bot or lock changes, so the state is
+	// read fresh for this bot rather than from the sync cache.
+	state, err := loadBotState(ctx, b.client, bot)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	res, err := getBotResource(bot, state.instances[resourceId.Resource], state.locked(bot))
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to create bot resource: %w", err)
+	}
+
+	return res, nil, nil
+}
+
+// getBotState returns the bot instances and locks, loading them on first
+// use. Concurrent callers wait for a single load.
+func (b *botBuilder) getBotState(ctx context.Context) (*botState, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state != nil {
+		return b.state, nil
+	}
+
+	state, err := loadBotState(ctx, b.client, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	b.state = state
+	return state, nil
+}
+
+// loadBotState reads the instances of bot and the locks in force targeting
+// its user or role, or those of every bot when bot is nil.
+func loadBotState(ctx context.Context, c *client.TeleportClient, bot *machineidv1.Bot) (*botState, error) {
+	var botName string
+	var targets []types.LockTarget
+	if bot != nil {
+		botName = bot.GetMetadata().GetName()
+		targets = []types.LockTarget{
+			{User: bot.GetStatus().GetUserName()},
+			{Role: bot.GetStatus().GetRoleName()},
+		}
+	}
+
+	instances, err := c.GetAllBotInstances(ctx, botName)
+	if err != nil {
+		return nil, fmt.Errorf("baton-teleport: failed to list bot instances: %w", err)
+	}
+
+	locks, err := c.GetInForceLocks(ctx, targets...)
+	if err != nil {
+		return nil, fmt.Errorf("baton-teleport: failed to list locks: %w", err)
+	}
+
+	state := &botState{instances: map[string][]*machineidv1.BotInstance{}, locks: locks}
+	for _, instance := range instances {
+		name := instance.GetSpec().GetBotName()
+		state.instances[name] = append(state.instances[name], instance)
+	}
+	return state, nil
+}
+
+func (b *botBuilder) Entitlements(_ context.Context, _ *v2.Resource, _ rs.SyncOpAttrs) ([]*v2.Entitlement, *rs.SyncOpResults, error) {
+	return nil, nil, nil
+}
+
+func (b *botBuilder) Grants(_ context.Context, _ *v2.Resource, _ rs.SyncOpAttrs) ([]*v2.Grant, *rs.SyncOpResults, error) {
+	return nil, nil, nil
+}
+
+// updateBotRoles replaces the roles of a bot with the result of update and
+// reports whether they changed. Only the roles field is written, so traits
+// and the session TTL are untouched.
+func updateBotRoles(ctx context.Context, c *client.TeleportClient, botName string, update func([]string) []string) (bool, error) {
+	bot, err := c.BotServiceClient().GetBot(ctx, &machineidv1.GetBotRequest{BotName: botName})
+	if err != nil {
+		return false, err
+	}
+
+	roles := update(slices.Clone(bot.GetSpec().GetRoles()))
+	if slices.Equal(roles, bot.GetSpec().GetRoles()) {
+		return false, nil
+	}
+
+	bot.Spec.Roles = roles
+	_, err = c.BotServiceClient().UpdateBot(ctx, &machineidv1.UpdateBotRequest{
+		Bot:        bot,
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{botRolesPath}},
+	})
+	return err == nil, err
+}
+
+func newBotBuilder(c *client.TeleportClient) *botBuilder {
+	return &botBuilder{
+		resourceType: botResourceType,
+		client:       c,
+	}
+}
@@ -0,0 +1,87 @@
+package connector
+
+import (
+	"testing"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	headerv1 "github.com/gravitational/teleport/api/gen/proto/go/teleport/header/v1"
+	machineidv1 "github.com/gravitational/teleport/api/gen/proto/go/teleport/machineid/v1"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+func TestGetBotResource(t *testing.T) {
+	bot := &machineidv1.Bot{
+		Metadata: &headerv1.Metadata{Name: "ci"},
+		Spec: &machineidv1.BotSpec{
+			Roles:         []string{"deployer"},
+			Traits:        []*machineidv1.Trait{{Name: "logins", Values: []string{"root"}}},
+			MaxSessionTtl: durationpb.New(12 * time.Hour),
+		},
+		Status: &machineidv1.BotStatus{UserName: "bot-ci", RoleName: "bot-ci"},
+	}
+	instances := []*machineidv1.BotInstance{
+		{Spec: &machineidv1.BotInstanceSpec{BotName: "ci", InstanceId: "a"}},
+		{Spec: &machineidv1.BotInstanceSpec{BotName: "ci", InstanceId: "b"}},
+	}
+
+	res, err := getBotResource(bot, instances, false)
+	require.NoError(t, err)
+	require.Equal(t, "ci", res.Id.Resource)
+	require.Equal(t, botResourceType.Id, res.Id.ResourceType)
+
+	trait, err := rs.GetUserTrait(res)
+	require.NoError(t, err)
+	require.Equal(t, v2.UserTrait_ACCOUNT_TYPE_SERVICE, trait.GetAccountType())
+	require.Equal(t, v2.UserTrait_Status_STATUS_ENABLED, trait.GetStatus().GetStatus())
+
+	profile := trait.GetProfile().AsMap()
+	require.Equal(t, []interface{}{"deployer"}, profile["roles"])
+	require.Equal(t, map[string]interface{}{"logins": []interface{}{"root"}}, profile["traits"])
+	require.Equal(t, "12h0m0s", profile["max_session_ttl"])
+	require.EqualValues(t, 2, profile["instance_count"])
+	require.Equal(t, []interface{}{"a", "b"}, profile["instance_ids"])
+}
+
+func TestLastBotHeartbeat(t *testing.T) {
+	first := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	latest := first.Add(time.Hour)
+	instances := []*machineidv1.BotInstance{
+		{Status: &machineidv1.BotInstanceStatus{
+			InitialHeartbeat: &machineidv1.BotInstanceStatusHeartbeat{RecordedAt: timestamppb.New(first)},
+		}},
+		{Status: &machineidv1.BotInstanceStatus{
+			LatestHeartbeats: []*machineidv1.BotInstanceStatusHeartbeat{{RecordedAt: timestamppb.New(latest)}},
+		}},
+		{},
+	}
+
+	require.Equal(t, latest, lastBotHeartbeat(instances))
+	require.True(t, lastBotHeartbeat(nil).IsZero())
+}
+
+func TestBotState_Locked(t *testing.T) {
+	bot := &machineidv1.Bot{
+		Metadata: &headerv1.Metadata{Name: "ci"},
+		Status:   &machineidv1.BotStatus{UserName: "bot-ci", RoleName: "bot-ci"},
+	}
+
+	userLock, err := types.NewLock("baton-bot-ci", types.LockSpecV2{Target: types.LockTarget{User: "bot-ci"}})
+	require.NoError(t, err)
+	otherLock, err := types.NewLock("baton-alice", types.LockSpecV2{Target: types.LockTarget{User: "alice"}})
+	require.NoError(t, err)
+
+	require.False(t, (&botState{locks: []types.Lock{otherLock}}).locked(bot))
+	require.True(t, (&botState{locks: []types.Lock{otherLock, userLock}}).locked(bot))
+
+	res, err := getBotResource(bot, nil, true)
+	require.NoError(t, err)
+	trait, err := rs.GetUserTrait(res)
+	require.NoError(t, err)
+	require.Equal(t, v2.UserTrait_Status_STATUS_DISABLED, trait.GetStatus().GetStatus())
+}
@@ -0,0 +1,104 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/gravitational/teleport/api/types"
+)
+
+// clusterIndex holds the roles of one cluster and the principals holding
+// each of them. It is built once per sync and shared by the role builder and
+// the builders that evaluate role conditions locally, so roles and users are
+// each read once per cluster. Roles and members are loaded separately on
+// first use, as resources reached by no denying role only need the roles.
+type clusterIndex struct {
+	mu      sync.Mutex
+	scope   *clusterScope
+	roles   []types.Role
+	members roleMemberIndex
+}
+
+// index returns the index of the cluster of scope for the sync syncID. The
+// indexes of every cluster are dropped when a new sync starts, so each sync
+// sees role and membership edits.
+func (c *clusterSet) index(scope *clusterScope, syncID string) *clusterIndex {
+	c.indexMu.Lock()
+	defer c.indexMu.Unlock()
+
+	if c.indexes == nil || c.indexSyncID != syncID {
+		c.indexes = map[string]*clusterIndex{}
+		c.indexSyncID = syncID
+	}
+
+	// The root scope of unnested resource types has no name, so indexes
+	// are keyed by leaf cluster name and the empty name for the root.
+	key := ""
+	if scope.leaf {
+		key = scope.name
+	}
+	idx, ok := c.indexes[key]
+	if !ok {
+		idx = &clusterIndex{scope: scope}
+		c.indexes[key] = idx
+	}
+	return idx
+}
+
+// Roles returns every role of the cluster, fetching them one page at a time
+// on first use.
+func (c *clusterIndex) Roles(ctx context.Context) ([]types.Role, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.roles != nil {
+		return c.roles, nil
+	}
+
+	roles := []types.Role{}
+	err := c.scope.client.ForEachRole(ctx, func(role types.Role) error {
+		roles = append(roles, role)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("baton-teleport: failed to list roles: %w", err)
+	}
+
+	c.roles = roles
+	return roles, nil
+}
+
+// Role returns the named role from the index, or nil if the cluster has no
+// such role.
+func (c *clusterIndex) Role(ctx context.Context, name string) (types.Role, error) {
+	roles, err := c.Roles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range roles {
+		if role.GetName() == name {
+			return role, nil
+		}
+	}
+	return nil, nil
+}
+
+// Members returns the principals holding each role, built on first use from
+// a single paginated pass over the users.
+func (c *clusterIndex) Members(ctx context.Context) (roleMemberIndex, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.members != nil {
+		return c.members, nil
+	}
+
+	members, err := loadRoleMemberIndex(ctx, c.scope)
+	if err != nil {
+		return nil, err
+	}
+
+	c.members = members
+	return members, nil
+}
@@ -0,0 +1,196 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/gravitational/trace"
+
+	"github.com/conductorone/baton-teleport/pkg/client"
+)
+
+// clusterIDSeparator separates the leaf cluster name from the Teleport name
+// in the IDs of leaf cluster resources, as in leaf.example.com/alice.
+const clusterIDSeparator = "/"
+
+// clusterScope is the Teleport cluster a builder call works on. Resources of
+// the root cluster keep their Teleport name as ID so existing grants are
+// unaffected; resources of a leaf cluster are prefixed with its name.
+type clusterScope struct {
+	// name is the cluster name. It is empty for users and roles of the root
+	// cluster when leaf clusters are not synced, as those are not nested.
+	name   string
+	leaf   bool
+	client *client.TeleportClient
+}
+
+// resourceID returns the resource ID of the named Teleport resource in this
+// cluster. A nil scope is the root cluster.
+func (s *clusterScope) resourceID(name string) string {
+	if s == nil || !s.leaf {
+		return name
+	}
+	return s.name + clusterIDSeparator + name
+}
+
+// teleportName returns the Teleport name of a resource ID in this cluster.
+func (s *clusterScope) teleportName(id string) string {
+	if s == nil || !s.leaf {
+		return id
+	}
+	return strings.TrimPrefix(id, s.name+clusterIDSeparator)
+}
+
+// parentID returns the ID of the cluster resource, or nil when clusters are
+// not synced as resources.
+func (s *clusterScope) parentID() *v2.ResourceId {
+	if s == nil || s.name == "" {
+		return nil
+	}
+	return &v2.ResourceId{ResourceType: clusterResourceType.Id, Resource: s.name}
+}
+
+// scopeResource moves a resource built from a Teleport object into this
+// cluster: its ID is namespaced and its parent is the cluster resource.
+func (s *clusterScope) scopeResource(resource *v2.Resource) *v2.Resource {
+	resource.Id.Resource = s.resourceID(resource.Id.Resource)
+	resource.ParentResourceId = s.parentID()
+	return resource
+}
+
+// clusterSet resolves the cluster a synced resource or a resource ID belongs
+// to. Unless leaf clusters are synced, everything is in the root cluster.
+// Infrastructure resources are always nested under their cluster resource;
+// users and roles only when leaf clusters are synced.
+type clusterSet struct {
+	root         *client.TeleportClient
+	leafClusters bool
+
+	mu       sync.Mutex
+	rootName string
+
+	// indexMu guards the role indexes of each cluster, which are shared by
+	// every builder for the duration of the sync indexSyncID.
+	indexMu     sync.Mutex
+	indexes     map[string]*clusterIndex
+	indexSyncID string
+}
+
+func newClusterSet(root *client.TeleportClient, leafClusters bool) *clusterSet {
+	return &clusterSet{root: root, leafClusters: leafClusters}
+}
+
+// childResourceTypes returns the resource types nested under a cluster
+// resource.
+func (c *clusterSet) childResourceTypes() []*v2.ResourceType {
+	if c.leafClusters {
+		return slices.Concat(clusterPrincipalResourceTypes, clusterTrustResourceTypes, clusterChildResourceTypes)
+	}
+	return clusterChildResourceTypes
+}
+
+// nested reports whether resources of the type are nested under a cluster
+// resource.
+func (c *clusterSet) nested(resourceTypeID string) bool {
+	return slices.ContainsFunc(c.childResourceTypes(), func(rt *v2.ResourceType) bool {
+		return rt.Id == resourceTypeID
+	})
+}
+
+// rootScope returns the scope of the root cluster for a resource type.
+func (c *clusterSet) rootScope(ctx context.Context, resourceTypeID string) (*clusterScope, error) {
+	if !c.nested(resourceTypeID) {
+		return &clusterScope{client: c.root}, nil
+	}
+
+	name, err := c.rootClusterName(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &clusterScope{name: name, client: c.root}, nil
+}
+
+func (c *clusterSet) rootClusterName(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.rootName != "" {
+		return c.rootName, nil
+	}
+
+	clusterName, err := c.root.GetClusterName(ctx)
+	if err != nil {
+		return "", fmt.Errorf("baton-teleport: failed to get cluster name: %w", err)
+	}
+
+	c.rootName = clusterName.GetClusterName()
+	return c.rootName, nil
+}
+
+// skipList reports whether a List call has nothing to return: nested
+// resources are only listed under their cluster resource.
+func (c *clusterSet) skipList(resourceTypeID string, parentResourceID *v2.ResourceId) bool {
+	return parentResourceID == nil && c.nested(resourceTypeID)
+}
+
+// scope returns the scope of the cluster identified by a parent resource ID,
+// or the root cluster when there is none.
+func (c *clusterSet) scope(ctx context.Context, resourceTypeID string, parentResourceID *v2.ResourceId) (*clusterScope, error) {
+	if !c.leafClusters || parentResourceID == nil || parentResourceID.ResourceType != clusterResourceType.Id {
+		return c.rootScope(ctx, resourceTypeID)
+	}
+
+	rootName, err := c.rootClusterName(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if parentResourceID.Resource == rootName {
+		return c.rootScope(ctx, resourceTypeID)
+	}
+
+	return c.leafScope(ctx, parentResourceID.Resource)
+}
+
+func (c *clusterSet) leafScope(ctx context.Context, name string) (*clusterScope, error) {
+	leaf, err := c.root.LeafClient(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("baton-teleport: failed to connect to leaf cluster %s: %w", name, err)
+	}
+	return &clusterScope{name: name, leaf: true, client: leaf}, nil
+}
+
+// scopeOf returns the scope of the cluster a resource ID belongs to and the
+// Teleport name it stands for. It is used where only IDs are known, such as
+// when granting and revoking.
+func (c *clusterSet) scopeOf(ctx context.Context, resourceTypeID, id string) (*clusterScope, string, error) {
+	if c.leafClusters {
+		if clusterName, name, ok := strings.Cut(id, clusterIDSeparator); ok && clusterName != "" {
+			_, err := c.root.GetRemoteCluster(ctx, clusterName)
+			switch {
+			case err == nil:
+				scope, err := c.leafScope(ctx, clusterName)
+				if err != nil {
+					return nil, "", err
+				}
+				return scope, name, nil
+			case !trace.IsNotFound(err):
+				return nil, "", fmt.Errorf("baton-teleport: failed to get remote cluster %s: %w", clusterName, err)
+			}
+		}
+	}
+
+	scope, err := c.rootScope(ctx, resourceTypeID)
+	if err != nil {
+		return nil, "", err
+	}
+	return scope, id, nil
+}
+
+// resourceScope returns the scope of a synced resource from its parent.
+func (c *clusterSet) resourceScope(ctx context.Context, resource *v2.Resource) (*clusterScope, error) {
+	return c.scope(ctx, resource.GetId().GetResourceType(), resource.GetParentResourceId())
+}
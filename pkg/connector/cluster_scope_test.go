@@ -0,0 +1,70 @@
+package connector
+
+import (
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusterScope(t *testing.T) {
+	var root *clusterScope
+	require.Equal(t, "alice", root.resourceID("alice"))
+	require.Equal(t, "alice", root.teleportName("alice"))
+	require.Nil(t, root.parentID())
+
+	named := &clusterScope{name: "root.example.com"}
+	require.Equal(t, "alice", named.resourceID("alice"))
+	require.Equal(t, &v2.ResourceId{ResourceType: clusterResourceType.Id, Resource: "root.example.com"}, named.parentID())
+
+	leaf := &clusterScope{name: "leaf.example.com", leaf: true}
+	require.Equal(t, "leaf.example.com/alice", leaf.resourceID("alice"))
+	require.Equal(t, "alice", leaf.teleportName("leaf.example.com/alice"))
+
+	resource := &v2.Resource{Id: &v2.ResourceId{ResourceType: userResourceType.Id, Resource: "alice"}}
+	leaf.scopeResource(resource)
+	require.Equal(t, "leaf.example.com/alice", resource.Id.Resource)
+	require.Equal(t, "leaf.example.com", resource.ParentResourceId.Resource)
+
+	name, err := principalName(leaf, &v2.ResourceId{ResourceType: userResourceType.Id, Resource: "leaf.example.com/alice"})
+	require.NoError(t, err)
+	require.Equal(t, "alice", name)
+
+	_, err = principalName(leaf, &v2.ResourceId{ResourceType: userResourceType.Id, Resource: "alice"})
+	require.Error(t, err)
+}
+
+func TestClusterSetNesting(t *testing.T) {
+	clusters := newClusterSet(nil, false)
+	require.True(t, clusters.nested(nodeResourceType.Id))
+	require.True(t, clusters.nested(windowsDesktopResourceType.Id))
+	require.False(t, clusters.nested(userResourceType.Id))
+	require.True(t, clusters.skipList(kubeClusterResourceType.Id, nil))
+	require.False(t, clusters.skipList(roleResourceType.Id, nil))
+	require.False(t, clusters.skipList(dbResourceType.Id, &v2.ResourceId{ResourceType: clusterResourceType.Id, Resource: "root.example.com"}))
+
+	leaves := newClusterSet(nil, true)
+	require.True(t, leaves.nested(userResourceType.Id))
+	require.True(t, leaves.skipList(roleResourceType.Id, nil))
+	require.False(t, leaves.nested(lockResourceType.Id))
+	require.True(t, leaves.nested(trustedClusterResourceType.Id))
+	require.False(t, clusters.nested(trustedClusterResourceType.Id))
+	require.Len(t, leaves.childResourceTypes(), len(clusterChildResourceTypes)+3)
+}
+
+func TestClusterSetIndex(t *testing.T) {
+	clusters := newClusterSet(nil, true)
+	root := &clusterScope{}
+	nestedRoot := &clusterScope{name: "root.example.com"}
+	leaf := &clusterScope{name: "leaf.example.com", leaf: true}
+
+	// Roles and infrastructure resources of the root cluster share an index
+	// whether or not their scope is named.
+	idx := clusters.index(root, "sync-1")
+	require.Same(t, idx, clusters.index(nestedRoot, "sync-1"))
+	require.NotSame(t, idx, clusters.index(leaf, "sync-1"))
+	require.Same(t, clusters.index(leaf, "sync-1"), clusters.index(leaf, "sync-1"))
+
+	// A new sync starts from fresh indexes.
+	require.NotSame(t, idx, clusters.index(root, "sync-2"))
+}
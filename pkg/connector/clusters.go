@@ -0,0 +1,138 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+// clusterChildResourceTypes are the infrastructure resource types nested
+// under their cluster resource.
+var clusterChildResourceTypes = []*v2.ResourceType{
+	nodeResourceType,
+	appResourceType,
+	dbResourceType,
+	kubeClusterResourceType,
+	windowsDesktopResourceType,
+}
+
+// clusterPrincipalResourceTypes are the resource types also nested under
+// their cluster resource when leaf clusters are synced, as every cluster has
+// its own users and roles.
+var clusterPrincipalResourceTypes = []*v2.ResourceType{
+	userResourceType,
+	roleResourceType,
+}
+
+// clusterTrustResourceTypes are nested under their cluster resource when
+// leaf clusters are synced too: trusted clusters live on the leaf clusters
+// and map roles of the root cluster to roles of the leaf.
+var clusterTrustResourceTypes = []*v2.ResourceType{
+	trustedClusterResourceType,
+}
+
+type clusterBuilder struct {
+	resourceType *v2.ResourceType
+	clusters     *clusterSet
+}
+
+func (c *clusterBuilder) ResourceType(_ context.Context) *v2.ResourceType {
+	return c.resourceType
+}
+
+// Create a new connector resource for a Teleport cluster, the parent of the
+// resources synced from it.
+func getClusterResource(name string, leaf bool, children []*v2.ResourceType) (*v2.Resource, error) {
+	description := "Root cluster"
+	if leaf {
+		description = "Leaf cluster"
+	}
+
+	opts := []rs.ResourceOption{rs.WithDescription(description)}
+	for _, child := range children {
+		opts = append(opts, rs.WithAnnotation(&v2.ChildResourceType{ResourceTypeId: child.Id}))
+	}
+
+	return rs.NewResource(name, clusterResourceType, name, opts...)
+}
+
+// List returns the root cluster and, when leaf clusters are synced, every
+// leaf cluster connected to it.
+func (c *clusterBuilder) List(ctx context.Context, _ *v2.ResourceId, _ rs.SyncOpAttrs) ([]*v2.Resource, *rs.SyncOpResults, error) {
+	rootName, err := c.clusters.rootClusterName(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	children := c.clusters.childResourceTypes()
+	root, err := getClusterResource(rootName, false, children)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to create cluster resource: %w", err)
+	}
+	rv := []*v2.Resource{root}
+	if !c.clusters.leafClusters {
+		return rv, nil, nil
+	}
+
+	leaves, err := c.clusters.root.GetRemoteClusters(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to list remote clusters: %w", err)
+	}
+
+	for _, leaf := range leaves {
+		cr, err := getClusterResource(leaf.GetName(), true, children)
+		if err != nil {
+			return nil, nil, fmt.Errorf("baton-teleport: failed to create cluster resource: %w", err)
+		}
+		rv = append(rv, cr)
+	}
+
+	return rv, nil, nil
+}
+
+func (c *clusterBuilder) Get(ctx context.Context, resourceId *v2.ResourceId, _ *v2.ResourceId) (*v2.Resource, annotations.Annotations, error) {
+	if resourceId == nil {
+		return nil, nil, fmt.Errorf("baton-teleport: resourceId is required")
+	}
+
+	rootName, err := c.clusters.rootClusterName(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	leaf := resourceId.Resource != rootName
+	if leaf {
+		if !c.clusters.leafClusters {
+			return nil, nil, fmt.Errorf("baton-teleport: cluster %s not found", resourceId.Resource)
+		}
+		if _, err := c.clusters.root.GetRemoteCluster(ctx, resourceId.Resource); err != nil {
+			return nil, nil, fmt.Errorf("baton-teleport: failed to get remote cluster %s: %w", resourceId.Resource, err)
+		}
+	}
+
+	cr, err := getClusterResource(resourceId.Resource, leaf, c.clusters.childResourceTypes())
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to create cluster resource: %w", err)
+	}
+
+	return cr, nil, nil
+}
+
+func (c *clusterBuilder) Entitlements(_ context.Context, _ *v2.Resource, _ rs.SyncOpAttrs) ([]*v2.Entitlement, *rs.SyncOpResults, error) {
+	return nil, nil, nil
+}
+
+func (c *clusterBuilder) Grants(_ context.Context, _ *v2.Resource, _ rs.SyncOpAttrs) ([]*v2.Grant, *rs.SyncOpResults, error) {
+	return nil, nil, nil
+}
+
+func newClusterBuilder(clusters *clusterSet) *clusterBuilder {
+	return &clusterBuilder{
+		resourceType: clusterResourceType,
+		clusters:     clusters,
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"time"
 
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
@@ -16,17 +17,34 @@ import (
 )
 
 type Connector struct {
-	client *client.TeleportClient
+	client               *client.TeleportClient
+	roleRuleEntitlements bool
+	leafClusters         bool
+	membershipTTL        time.Duration
 }
 
 // ResourceSyncers returns a ResourceSyncer for each resource type that should be synced from the upstream service.
 func (d *Connector) ResourceSyncers(ctx context.Context) []connectorbuilder.ResourceSyncerV2 {
+	clusters := newClusterSet(d.client, d.leafClusters)
 	return []connectorbuilder.ResourceSyncerV2{
-		newUserBuilder(d.client),
-		newRoleBuilder(d.client),
-		newNodeBuilder(d.client),
-		newAppBuilder(d.client),
-		newDatabaseBuilder(d.client),
+		newClusterBuilder(clusters),
+		newUserBuilder(d.client, clusters),
+		newRoleBuilder(clusters, d.roleRuleEntitlements),
+		newNodeBuilder(clusters),
+		newAppBuilder(clusters),
+		newDatabaseBuilder(clusters),
+		newKubeClusterBuilder(clusters),
+		newWindowsDesktopBuilder(clusters),
+		newAccessListBuilder(d.client, d.membershipTTL),
+		newBotBuilder(d.client),
+		newLockBuilder(d.client),
+		newSSOConnectorBuilder(d.client, samlConnectorKind),
+		newSSOConnectorBuilder(d.client, oidcConnectorKind),
+		newSSOConnectorBuilder(d.client, githubConnectorKind),
+		newSSOGroupBuilder(d.client),
+		newTrustedClusterBuilder(clusters),
+		newRemoteClusterBuilder(d.client),
+		newProvisionTokenBuilder(d.client),
 	}
 }
 
@@ -87,18 +105,30 @@ func (d *Connector) Close() error {
 func (d *Connector) EventFeeds(ctx context.Context) []connectorbuilder.EventFeed {
 	return []connectorbuilder.EventFeed{
 		newUsageEventFeed(d.client),
-		newAuditEventFeed(d.client),
+		newAuditEventFeed(d.client, d.leafClusters),
 	}
 }
 
 // New returns a new instance of the connector.
 func New(ctx context.Context, c *cfg.Teleport, opts *cli.ConnectorOpts) (connectorbuilder.ConnectorBuilderV2, []connectorbuilder.Opt, error) {
+	var membershipTTL time.Duration
+	if c.AccessListMembershipTtl != "" {
+		ttl, err := time.ParseDuration(c.AccessListMembershipTtl)
+		if err != nil || ttl <= 0 {
+			return nil, nil, fmt.Errorf("baton-teleport: invalid access list membership ttl %q", c.AccessListMembershipTtl)
+		}
+		membershipTTL = ttl
+	}
+
 	tc, err := client.New(ctx, c.TeleportProxyAddress, c.TeleportKeyPath, c.TeleportKey)
 	if err != nil {
 		return nil, nil, fmt.Errorf("baton-teleport: failed to create teleport client: %w", err)
 	}
 
 	return &Connector{
-		client: tc,
+		client:               tc,
+		roleRuleEntitlements: c.RoleRuleEntitlements,
+		leafClusters:         c.LeafClusters,
+		membershipTTL:        membershipTTL,
 	}, nil, nil
 }
@@ -5,27 +5,36 @@ import (
 	"fmt"
 
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
 	"github.com/gravitational/teleport/api/types"
 
 	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
 	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
-	"github.com/conductorone/baton-teleport/pkg/client"
 )
 
 const dbMembership = "member"
 
+// dbValueKinds are the role values modelled as per-value entitlements on a
+// database.
+var dbValueKinds = []roleValueKind{
+	{prefix: "db_user", description: "database user", values: types.Role.GetDatabaseUsers},
+	{prefix: "db_name", description: "database name", values: types.Role.GetDatabaseNames},
+}
+
 type dbBuilder struct {
 	resourceType *v2.ResourceType
-	client       *client.TeleportClient
+	clusters     *clusterSet
 }
 
 func (d *dbBuilder) ResourceType(_ context.Context) *v2.ResourceType {
 	return d.resourceType
 }
 
-// Create a new connector resource for a Teleport node.
+// Create a new connector resource for a Teleport database.
+// Database names are unique within a cluster and stable across edits, so they
+// are used as the resource ID; audit events reference databases by name too.
 func getDatabaseResource(db types.Database) (*v2.Resource, error) {
-	dbId := db.GetRevision()
+	dbId := db.GetName()
 	return rs.NewRoleResource(
 		db.GetName(),
 		dbResourceType,
@@ -33,19 +42,32 @@ func getDatabaseResource(db types.Database) (*v2.Resource, error) {
 		[]rs.RoleTraitOption{
 			rs.WithRoleProfile(
 				map[string]interface{}{
-					"db_id":   dbId,
-					"db_name": db.GetName(),
+					"db_id":          dbId,
+					"db_name":        db.GetName(),
+					"protocol":       db.GetProtocol(),
+					"revision":       db.GetRevision(),
+					labelsProfileKey: labelsProfile(db.GetAllLabels()),
 				},
 			),
 		},
+		legacyIDOptions(db.GetRevision())...,
 	)
 }
 
 // List returns all the databases from the database as resource objects.
 // Databases include a NodeTrait because they are the 'shape' of a standard db.
-func (d *dbBuilder) List(ctx context.Context, _ *v2.ResourceId, _ rs.SyncOpAttrs) ([]*v2.Resource, *rs.SyncOpResults, error) {
+func (d *dbBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, _ rs.SyncOpAttrs) ([]*v2.Resource, *rs.SyncOpResults, error) {
 	var rv []*v2.Resource
-	databases, err := d.client.GetDatabases(ctx)
+	if d.clusters.skipList(d.resourceType.Id, parentResourceID) {
+		return nil, nil, nil
+	}
+
+	scope, err := d.clusters.scope(ctx, d.resourceType.Id, parentResourceID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	databases, err := scope.client.GetDatabases(ctx)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -56,31 +78,73 @@ func (d *dbBuilder) List(ctx context.Context, _ *v2.ResourceId, _ rs.SyncOpAttrs
 		if err != nil {
 			return nil, nil, err
 		}
-		rv = append(rv, rr)
+		rv = append(rv, scope.scopeResource(rr))
 	}
 
 	return rv, nil, nil
 }
 
-func (d *dbBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ rs.SyncOpAttrs) ([]*v2.Entitlement, *rs.SyncOpResults, error) {
-	return []*v2.Entitlement{
+func (d *dbBuilder) Get(ctx context.Context, resourceId *v2.ResourceId, _ *v2.ResourceId) (*v2.Resource, annotations.Annotations, error) {
+	if resourceId == nil {
+		return nil, nil, fmt.Errorf("baton-teleport: resourceId is required")
+	}
+
+	scope, name, err := d.clusters.scopeOf(ctx, d.resourceType.Id, resourceId.Resource)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db, err := scope.client.GetDatabase(ctx, name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to get database %s: %w", resourceId.Resource, err)
+	}
+
+	res, err := getDatabaseResource(db)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return scope.scopeResource(res), nil, nil
+}
+
+// Entitlements returns the database membership entitlement plus one
+// entitlement per database user and database name allowed by a role that can
+// reach the database.
+func (d *dbBuilder) Entitlements(ctx context.Context, resource *v2.Resource, opts rs.SyncOpAttrs) ([]*v2.Entitlement, *rs.SyncOpResults, error) {
+	access, err := resolveResourceAccess(ctx, d.clusters, resource, types.KindDatabase, dbValueKinds, opts.SyncID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to compute database entitlements: %w", err)
+	}
+
+	rv := []*v2.Entitlement{
 		ent.NewAssignmentEntitlement(
 			resource,
 			dbMembership,
-			ent.WithGrantableTo(userResourceType),
+			ent.WithGrantableTo(userResourceType, roleResourceType),
 			ent.WithDisplayName(fmt.Sprintf("%s Database %s", resource.DisplayName, dbMembership)),
 			ent.WithDescription(fmt.Sprintf("Member of %s Teleport db", resource.DisplayName)),
 		),
-	}, nil, nil
+	}
+
+	return append(rv, access.entitlements(resource, dbValueKinds)...), nil, nil
 }
 
-func (d *dbBuilder) Grants(_ context.Context, _ *v2.Resource, _ rs.SyncOpAttrs) ([]*v2.Grant, *rs.SyncOpResults, error) {
-	return nil, nil, nil
+// Grants returns a membership grant for every role whose db_labels match the
+// database, plus the db_user and db_name grants those roles carry. Roles get
+// expandable grants; users receiving a value through a trait template such as
+// {{internal.db_users}} get a direct grant.
+func (d *dbBuilder) Grants(ctx context.Context, resource *v2.Resource, opts rs.SyncOpAttrs) ([]*v2.Grant, *rs.SyncOpResults, error) {
+	access, err := resolveResourceAccess(ctx, d.clusters, resource, types.KindDatabase, dbValueKinds, opts.SyncID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to compute database grants: %w", err)
+	}
+
+	return access.grants(resource, dbMembership, dbValueKinds), nil, nil
 }
 
-func newDatabaseBuilder(c *client.TeleportClient) *dbBuilder {
+func newDatabaseBuilder(clusters *clusterSet) *dbBuilder {
 	return &dbBuilder{
 		resourceType: dbResourceType,
-		client:       c,
+		clusters:     clusters,
 	}
 }
@@ -33,16 +33,41 @@ type eventPageCursor struct {
 // a fresh cursor whose StartAt is set to defaultStart (or 24 h ago when nil).
 func unmarshalEventPageCursor(pToken *pagination.StreamToken, defaultStart *timestamppb.Timestamp) (*eventPageCursor, error) {
 	c := &eventPageCursor{}
-	if pToken != nil && pToken.Cursor != "" {
-		data, err := base64.StdEncoding.DecodeString(pToken.Cursor)
-		if err != nil {
-			return nil, fmt.Errorf("baton-teleport: failed to decode page cursor: %w", err)
-		}
-		if err := json.Unmarshal(data, c); err != nil {
-			return nil, fmt.Errorf("baton-teleport: failed to unmarshal page cursor: %w", err)
-		}
+	if err := decodeCursor(pToken, c); err != nil {
+		return nil, err
+	}
+	c.setDefaults(defaultStart)
+	return c, nil
+}
+
+// decodeCursor unmarshals the cursor of pToken into c, leaving c untouched
+// when there is no cursor yet.
+func decodeCursor(pToken *pagination.StreamToken, c any) error {
+	if pToken == nil || pToken.Cursor == "" {
+		return nil
+	}
+	data, err := base64.StdEncoding.DecodeString(pToken.Cursor)
+	if err != nil {
+		return fmt.Errorf("baton-teleport: failed to decode page cursor: %w", err)
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("baton-teleport: failed to unmarshal page cursor: %w", err)
 	}
+	return nil
+}
 
+// encodeCursor marshals c into the string stored in
+// pagination.StreamState.Cursor.
+func encodeCursor(c any) (string, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("baton-teleport: failed to marshal page cursor: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// setDefaults starts a fresh cursor at defaultStart, or 24 h ago when nil.
+func (c *eventPageCursor) setDefaults(defaultStart *timestamppb.Timestamp) {
 	if c.StartAt == "" {
 		var start time.Time
 		if defaultStart != nil {
@@ -56,18 +81,12 @@ func unmarshalEventPageCursor(pToken *pagination.StreamToken, defaultStart *time
 	if c.LatestEventSeen == "" {
 		c.LatestEventSeen = c.StartAt
 	}
-
-	return c, nil
 }
 
 // marshal serialises the cursor to a base64-encoded JSON string ready to be
 // stored in pagination.StreamState.Cursor.
 func (c *eventPageCursor) marshal() (string, error) {
-	data, err := json.Marshal(c)
-	if err != nil {
-		return "", fmt.Errorf("baton-teleport: failed to marshal page cursor: %w", err)
-	}
-	return base64.StdEncoding.EncodeToString(data), nil
+	return encodeCursor(c)
 }
 
 // updateLatestEvent advances LatestEventSeen if t is strictly after the
@@ -90,3 +109,41 @@ func (c *eventPageCursor) prepareNextSync() {
 	c.LatestEventSeen = ""
 	c.LastKey = ""
 }
+
+// auditFeedCursor is the pagination state of the audit event feed, which
+// reads the audit log of every cluster. The position in the root cluster's
+// log is kept at the top level, so cursors stored before leaf clusters were
+// read still resume; each leaf cluster has its own position in Leaves,
+// keyed by cluster name.
+type auditFeedCursor struct {
+	eventPageCursor
+	Leaves map[string]*eventPageCursor `json:"leaves,omitempty"`
+}
+
+// unmarshalAuditFeedCursor deserialises the cursor from pToken, or
+// initialises a fresh one whose root position starts at defaultStart.
+func unmarshalAuditFeedCursor(pToken *pagination.StreamToken, defaultStart *timestamppb.Timestamp) (*auditFeedCursor, error) {
+	c := &auditFeedCursor{}
+	if err := decodeCursor(pToken, c); err != nil {
+		return nil, err
+	}
+	c.setDefaults(defaultStart)
+	return c, nil
+}
+
+// leaf returns the position in the audit log of the named leaf cluster. A
+// leaf seen for the first time starts at defaultStart.
+func (c *auditFeedCursor) leaf(name string, defaultStart *timestamppb.Timestamp) *eventPageCursor {
+	if leaf, ok := c.Leaves[name]; ok {
+		leaf.setDefaults(defaultStart)
+		return leaf
+	}
+	leaf := &eventPageCursor{}
+	leaf.setDefaults(defaultStart)
+	return leaf
+}
+
+// marshal serialises the whole cursor, including the leaf positions.
+func (c *auditFeedCursor) marshal() (string, error) {
+	return encodeCursor(c)
+}
@@ -5,7 +5,9 @@ import (
 	"regexp"
 	"strings"
 
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
 )
 
 // PopulateOptions - Populate entitlement options for teleport resource.
@@ -35,3 +37,33 @@ func splitDashSeparatedName(name string) (string, string) {
 	}
 	return firstName, lastName
 }
+
+// legacyIDOptions keeps the Metadata.Revision used as the resource ID by
+// earlier connector versions as an alias, so C1 can match apps, databases and
+// nodes synced by those versions to their stable name-based ID. The alias
+// only matches while the revision is unchanged since that last sync.
+func legacyIDOptions(revision string) []rs.ResourceOption {
+	if revision == "" {
+		return nil
+	}
+	return []rs.ResourceOption{rs.WithAliases(revision)}
+}
+
+// stringsToInterfaces converts a string slice into a list value accepted by
+// resource profiles.
+func stringsToInterfaces(values []string) []interface{} {
+	rv := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		rv = append(rv, v)
+	}
+	return rv
+}
+
+// entitlementSlug returns the slug of an entitlement. Entitlements attached to
+// grants may only carry their ID, which ends in the slug.
+func entitlementSlug(entitlement *v2.Entitlement) string {
+	if entitlement.Slug != "" {
+		return entitlement.Slug
+	}
+	return entitlement.Id[strings.LastIndex(entitlement.Id, ":")+1:]
+}
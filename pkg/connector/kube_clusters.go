@@ -0,0 +1,169 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/gravitational/teleport/api/types"
+
+	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+const kubeClusterMembership = "member"
+
+// kubeValueKinds are the role values modelled as per-value entitlements on a
+// Kubernetes cluster: the groups and users impersonated on the cluster and
+// the resource rules scoping what those identities may touch.
+var kubeValueKinds = []roleValueKind{
+	{prefix: "kube_group", description: "Kubernetes group", values: types.Role.GetKubeGroups},
+	{prefix: "kube_user", description: "Kubernetes user", values: types.Role.GetKubeUsers},
+	{prefix: "kube_resource", description: "Kubernetes resource rule", values: kubeResourceValues},
+}
+
+type kubeClusterBuilder struct {
+	resourceType *v2.ResourceType
+	clusters     *clusterSet
+}
+
+func (k *kubeClusterBuilder) ResourceType(_ context.Context) *v2.ResourceType {
+	return k.resourceType
+}
+
+// Create a new connector resource for a Teleport Kubernetes cluster.
+func getKubeClusterResource(cluster types.KubeCluster) (*v2.Resource, error) {
+	return rs.NewRoleResource(
+		cluster.GetName(),
+		kubeClusterResourceType,
+		cluster.GetName(),
+		[]rs.RoleTraitOption{
+			rs.WithRoleProfile(
+				map[string]interface{}{
+					"kube_cluster_name": cluster.GetName(),
+					"description":       cluster.GetMetadata().Description,
+					labelsProfileKey:    labelsProfile(cluster.GetAllLabels()),
+				},
+			),
+		},
+	)
+}
+
+// kubeResourceValues renders the kubernetes_resources rules of a role as
+// "<kind>:<namespace>/<name>:<verbs>" values, prefixing the kind with its API
+// group when set. Cluster-scoped rules have no namespace part.
+func kubeResourceValues(role types.Role, rct types.RoleConditionType) []string {
+	resources := role.GetKubeResources(rct)
+	rv := make([]string, 0, len(resources))
+	for _, r := range resources {
+		kind := r.Kind
+		if r.APIGroup != "" {
+			kind = r.APIGroup + "/" + kind
+		}
+		verbs := r.Verbs
+		if len(verbs) == 0 {
+			verbs = []string{types.Wildcard}
+		}
+		name := r.Name
+		if r.Namespace != "" {
+			name = r.Namespace + "/" + name
+		}
+		rv = append(rv, fmt.Sprintf("%s:%s:%s", kind, name, strings.Join(verbs, ",")))
+	}
+	return rv
+}
+
+// List returns all the Kubernetes clusters registered in Teleport as resource objects.
+func (k *kubeClusterBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, opts rs.SyncOpAttrs) ([]*v2.Resource, *rs.SyncOpResults, error) {
+	var rv []*v2.Resource
+	if k.clusters.skipList(k.resourceType.Id, parentResourceID) {
+		return nil, nil, nil
+	}
+
+	scope, err := k.clusters.scope(ctx, k.resourceType.Id, parentResourceID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	clusters, nextToken, err := scope.client.GetKubeClusters(ctx, &pagination.Token{Token: opts.PageToken.Token, Size: opts.PageToken.Size})
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to list kubernetes clusters: %w", err)
+	}
+
+	for _, cluster := range clusters {
+		rr, err := getKubeClusterResource(cluster)
+		if err != nil {
+			return nil, nil, fmt.Errorf("baton-teleport: failed to create kubernetes cluster resource: %w", err)
+		}
+		rv = append(rv, scope.scopeResource(rr))
+	}
+
+	return rv, &rs.SyncOpResults{NextPageToken: nextToken}, nil
+}
+
+func (k *kubeClusterBuilder) Get(ctx context.Context, resourceId *v2.ResourceId, _ *v2.ResourceId) (*v2.Resource, annotations.Annotations, error) {
+	if resourceId == nil {
+		return nil, nil, fmt.Errorf("baton-teleport: resourceId is required")
+	}
+
+	scope, name, err := k.clusters.scopeOf(ctx, k.resourceType.Id, resourceId.Resource)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cluster, err := scope.client.GetKubernetesCluster(ctx, name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to get kubernetes cluster %s: %w", resourceId.Resource, err)
+	}
+
+	res, err := getKubeClusterResource(cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return scope.scopeResource(res), nil, nil
+}
+
+// Entitlements returns the cluster membership entitlement plus one
+// entitlement per Kubernetes group, user and resource rule allowed by a role
+// that can reach the cluster.
+func (k *kubeClusterBuilder) Entitlements(ctx context.Context, resource *v2.Resource, opts rs.SyncOpAttrs) ([]*v2.Entitlement, *rs.SyncOpResults, error) {
+	access, err := resolveResourceAccess(ctx, k.clusters, resource, types.KindKubernetesCluster, kubeValueKinds, opts.SyncID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to compute kubernetes cluster entitlements: %w", err)
+	}
+
+	rv := []*v2.Entitlement{
+		ent.NewAssignmentEntitlement(
+			resource,
+			kubeClusterMembership,
+			ent.WithGrantableTo(userResourceType, roleResourceType),
+			ent.WithDisplayName(fmt.Sprintf("%s Kubernetes Cluster %s", resource.DisplayName, kubeClusterMembership)),
+			ent.WithDescription(fmt.Sprintf("Member of %s Teleport kubernetes cluster", resource.DisplayName)),
+		),
+	}
+
+	return append(rv, access.entitlements(resource, kubeValueKinds)...), nil, nil
+}
+
+// Grants returns a membership grant for every role whose kubernetes_labels
+// match the cluster, plus the kubernetes_groups, kubernetes_users and
+// kubernetes_resources grants those roles carry.
+func (k *kubeClusterBuilder) Grants(ctx context.Context, resource *v2.Resource, opts rs.SyncOpAttrs) ([]*v2.Grant, *rs.SyncOpResults, error) {
+	access, err := resolveResourceAccess(ctx, k.clusters, resource, types.KindKubernetesCluster, kubeValueKinds, opts.SyncID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to compute kubernetes cluster grants: %w", err)
+	}
+
+	return access.grants(resource, kubeClusterMembership, kubeValueKinds), nil, nil
+}
+
+func newKubeClusterBuilder(clusters *clusterSet) *kubeClusterBuilder {
+	return &kubeClusterBuilder{
+		resourceType: kubeClusterResourceType,
+		clusters:     clusters,
+	}
+}
@@ -0,0 +1,110 @@
+package connector
+
+import (
+	"testing"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+func TestGetLockResource(t *testing.T) {
+	expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	lock, err := types.NewLock("baton-alice", types.LockSpecV2{
+		Target:    types.LockTarget{User: "alice"},
+		Message:   "compromised",
+		Expires:   &expires,
+		CreatedBy: "admin",
+	})
+	require.NoError(t, err)
+
+	res, err := getLockResource(lock)
+	require.NoError(t, err)
+	require.Equal(t, lockResourceType.Id, res.Id.ResourceType)
+	require.Equal(t, "baton-alice", res.Id.Resource)
+
+	trait, err := rs.GetRoleTrait(res)
+	require.NoError(t, err)
+	profile := trait.GetProfile().AsMap()
+	require.Equal(t, map[string]interface{}{"user": "alice"}, profile["target"])
+	require.Equal(t, "compromised", profile["message"])
+	require.Equal(t, "2030-01-02T03:04:05Z", profile["expires"])
+	require.Equal(t, true, profile["in_force"])
+}
+
+func TestUserActionTarget(t *testing.T) {
+	args := func(id *v2.ResourceId) *structpb.Struct {
+		rv, err := structpb.NewStruct(map[string]interface{}{
+			userActionResourceKey: map[string]interface{}{
+				"resource_type_id": id.ResourceType,
+				"resource_id":      id.Resource,
+			},
+		})
+		require.NoError(t, err)
+		return rv
+	}
+
+	name, err := userActionTarget(args(&v2.ResourceId{ResourceType: userResourceType.Id, Resource: "alice"}))
+	require.NoError(t, err)
+	require.Equal(t, "alice", name)
+	require.Equal(t, "baton-alice", userLockName(name))
+
+	_, err = userActionTarget(args(&v2.ResourceId{ResourceType: roleResourceType.Id, Resource: "access"}))
+	require.Error(t, err)
+
+	_, err = userActionTarget(&structpb.Struct{})
+	require.Error(t, err)
+}
+
+func TestUserSessionIDs(t *testing.T) {
+	tracker := func(id, host string, state types.SessionState, participants ...string) types.SessionTracker {
+		spec := types.SessionTrackerSpecV1{
+			SessionID: id,
+			Kind:      string(types.SSHSessionKind),
+			HostUser:  host,
+			State:     state,
+		}
+		for _, user := range participants {
+			spec.Participants = append(spec.Participants, types.Participant{User: user})
+		}
+		st, err := types.NewSessionTracker(spec)
+		require.NoError(t, err)
+		return st
+	}
+
+	trackers := []types.SessionTracker{
+		tracker("hosted", "alice", types.SessionState_SessionStateRunning),
+		tracker("joined", "bob", types.SessionState_SessionStatePending, "bob", "alice"),
+		tracker("ended", "alice", types.SessionState_SessionStateTerminated),
+		tracker("other", "bob", types.SessionState_SessionStateRunning, "bob"),
+	}
+
+	require.Equal(t, []string{"hosted", "joined"}, userSessionIDs(trackers, "alice"))
+	require.Empty(t, userSessionIDs(trackers, "carol"))
+}
+
+func TestUserResource_LockStatus(t *testing.T) {
+	user, err := types.NewUser("alice")
+	require.NoError(t, err)
+	// A failed-login lockout is not a lock and leaves the user enabled.
+	user.SetLocked(time.Now().Add(time.Hour), "too many failed login attempts")
+
+	for _, tc := range []struct {
+		locked bool
+		want   v2.UserTrait_Status_Status
+	}{
+		{locked: false, want: v2.UserTrait_Status_STATUS_ENABLED},
+		{locked: true, want: v2.UserTrait_Status_STATUS_DISABLED},
+	} {
+		res, err := userResource(nil, user, tc.locked)
+		require.NoError(t, err)
+
+		trait, err := rs.GetUserTrait(res)
+		require.NoError(t, err)
+		require.Equal(t, tc.want, trait.GetStatus().GetStatus())
+	}
+}
@@ -5,24 +5,47 @@ import (
 	"fmt"
 
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
 	"github.com/conductorone/baton-sdk/pkg/pagination"
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/trace"
 
 	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
 	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
-	"github.com/conductorone/baton-teleport/pkg/client"
 )
 
 const nodeMembership = "member"
 
+// nodeValueKinds models every OS login a node accepts as its own entitlement.
+var nodeValueKinds = []roleValueKind{
+	{prefix: "login", description: "SSH login", values: types.Role.GetLogins},
+}
+
 type nodeBuilder struct {
 	resourceType *v2.ResourceType
-	client       *client.TeleportClient
+	clusters     *clusterSet
 }
 
 type Node struct {
 	Id        string
 	Name      string
 	Namespace string
+	Revision  string
+	Labels    map[string]string
+}
+
+// newNode converts a Teleport SSH server into a Node. The server name is the
+// host UUID, which is stable for the lifetime of the node, unlike its
+// revision which changes on every heartbeat-driven update.
+func newNode(server types.Server) *Node {
+	return &Node{
+		Id:        server.GetName(),
+		Name:      server.GetHostname(),
+		Namespace: server.GetNamespace(),
+		Revision:  server.GetRevision(),
+		Labels:    server.GetAllLabels(),
+	}
 }
 
 func (n *nodeBuilder) ResourceType(_ context.Context) *v2.ResourceType {
@@ -37,93 +60,113 @@ func getNodeResource(node *Node) (*v2.Resource, error) {
 		node.Id,
 		[]rs.RoleTraitOption{
 			rs.WithRoleProfile(map[string]interface{}{
-				"node_id":   node.Id,
-				"node_name": node.Name,
-				"namespace": node.Namespace,
+				"node_id":        node.Id,
+				"node_name":      node.Name,
+				"namespace":      node.Namespace,
+				"revision":       node.Revision,
+				labelsProfileKey: labelsProfile(node.Labels),
 			}),
 		},
+		legacyIDOptions(node.Revision)...,
 	)
 }
 
 // List returns all the nodes from the database as resource objects.
 // Nodes include a NodeTrait because they are the 'shape' of a standard node.
-func (n *nodeBuilder) List(ctx context.Context, _ *v2.ResourceId, opts rs.SyncOpAttrs) ([]*v2.Resource, *rs.SyncOpResults, error) {
+func (n *nodeBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, opts rs.SyncOpAttrs) ([]*v2.Resource, *rs.SyncOpResults, error) {
 	var rv []*v2.Resource
-	resp, err := n.client.GetNodes(ctx, &pagination.Token{Token: opts.PageToken.Token})
+	if n.clusters.skipList(n.resourceType.Id, parentResourceID) {
+		return nil, nil, nil
+	}
+
+	scope, err := n.clusters.scope(ctx, n.resourceType.Id, parentResourceID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := scope.client.GetNodes(ctx, &pagination.Token{Token: opts.PageToken.Token})
 	if err != nil {
 		return nil, nil, fmt.Errorf("baton-teleport: failed to list nodes: %w", err)
 	}
 
 	for _, nodeWrapper := range resp.GetResources() {
-		node := nodeWrapper.GetNode()
-		rr, err := getNodeResource(&Node{
-			Id:        node.GetRevision(),
-			Name:      node.GetHostname(),
-			Namespace: node.GetNamespace(),
-		})
+		rr, err := getNodeResource(newNode(nodeWrapper.GetNode()))
 		if err != nil {
 			return nil, nil, fmt.Errorf("baton-teleport: failed to create node resource: %w", err)
 		}
-		rv = append(rv, rr)
+		rv = append(rv, scope.scopeResource(rr))
 	}
 
 	return rv, &rs.SyncOpResults{NextPageToken: resp.NextKey}, nil
 }
 
-func (r *nodeBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ rs.SyncOpAttrs) ([]*v2.Entitlement, *rs.SyncOpResults, error) {
-	return []*v2.Entitlement{
+func (n *nodeBuilder) Get(ctx context.Context, resourceId *v2.ResourceId, _ *v2.ResourceId) (*v2.Resource, annotations.Annotations, error) {
+	if resourceId == nil {
+		return nil, nil, fmt.Errorf("baton-teleport: resourceId is required")
+	}
+
+	scope, name, err := n.clusters.scopeOf(ctx, n.resourceType.Id, resourceId.Resource)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	node, err := scope.client.GetNode(ctx, apidefaults.Namespace, name)
+	if trace.IsNotFound(err) {
+		// An instance.join event names agents that run no SSH service and
+		// have no node: there is nothing to sync.
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to get node %s: %w", resourceId.Resource, err)
+	}
+
+	res, err := getNodeResource(newNode(node))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return scope.scopeResource(res), nil, nil
+}
+
+// Entitlements returns the node membership entitlement plus one entitlement
+// per OS login a role reaching the node allows, so that access as root can be
+// reviewed separately from access as an unprivileged user.
+func (r *nodeBuilder) Entitlements(ctx context.Context, resource *v2.Resource, opts rs.SyncOpAttrs) ([]*v2.Entitlement, *rs.SyncOpResults, error) {
+	access, err := resolveResourceAccess(ctx, r.clusters, resource, types.KindNode, nodeValueKinds, opts.SyncID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to compute node entitlements: %w", err)
+	}
+
+	rv := []*v2.Entitlement{
 		ent.NewAssignmentEntitlement(
 			resource,
 			nodeMembership,
-			ent.WithGrantableTo(userResourceType),
+			ent.WithGrantableTo(userResourceType, roleResourceType),
 			ent.WithDisplayName(fmt.Sprintf("%s Node %s", resource.DisplayName, nodeMembership)),
 			ent.WithDescription(fmt.Sprintf("Member of %s Teleport node", resource.DisplayName)),
 		),
-	}, nil, nil
+	}
+
+	return append(rv, access.entitlements(resource, nodeValueKinds)...), nil, nil
 }
 
-// TODO: This should return grants based on who has access to the node resource
-// ISSUE: TLDR: we need a way to associate nodes and roles
-// ISSUE: this is more complicated than initially thought. we need to find what roles
-// a user needs to access any given node, and then return the grants for those resources
-// currently the GetAccessCapabilities should return these values, but is either erroring out
-// or returning and empty list, we need to figure out a way to make that function run properly.
-func (r *nodeBuilder) Grants(_ context.Context, _ *v2.Resource, _ rs.SyncOpAttrs) ([]*v2.Grant, *rs.SyncOpResults, error) {
-	// nodes, err := r.client.ListResources(ctx, proto.ListResourcesRequest{
-	// 	ResourceType: types.KindNode,
-	// 	StartKey:     token.Token,
-	// })
-
-	// for _, n := range nodes.GetResources() {
-	// 	accessCapabilitiesRequest, err := r.client.GetAccessCapabilities(ctx, types.AccessCapabilitiesRequest{
-	// 		RequestableRoles: true,
-	// 		ResourceIDs: []types.ResourceID{
-	// 			{
-	// 				ClusterName: n.GetNode().GetNamespace(),
-	// 				Kind:        n.GetNode().GetKind(),
-	// 				Name:        n.GetNode().GetName(),
-	// 			},
-	// 		},
-	// 	})
-	// 	if err != nil {
-	// 		return nil, "", nil, err
-	// 	}
-	//
-	//  NOTE: should return the resources applicable roles but is empty or errors out
-	//	fmt.Println(fmt.Sprintf("accessCapabilitiesRequest.ApplicableRolesForResources: %+v", accessCapabilitiesRequest.ApplicableRolesForResources))
-	// }
-	//
-	// for _, user := range users {
-	// 	fmt.Println(fmt.Sprintf("roles: %+v", user.GetRoles())) // return's user's roles
-	// 	fmt.Println(fmt.Sprintf("user.GetTraits(): %+v", user.GetTraits())) // returns user's resources
-	// }
-
-	return nil, nil, nil
+// Grants returns a grant on the node for every role whose node_labels match
+// the node's labels and whose deny rules do not exclude it, plus the login
+// grants those roles carry. Role grants expand to the members of each role;
+// logins expanded from a trait template such as {{internal.logins}} are
+// granted to users directly.
+func (r *nodeBuilder) Grants(ctx context.Context, resource *v2.Resource, opts rs.SyncOpAttrs) ([]*v2.Grant, *rs.SyncOpResults, error) {
+	access, err := resolveResourceAccess(ctx, r.clusters, resource, types.KindNode, nodeValueKinds, opts.SyncID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to compute node grants: %w", err)
+	}
+
+	return access.grants(resource, nodeMembership, nodeValueKinds), nil, nil
 }
 
-func newNodeBuilder(c *client.TeleportClient) *nodeBuilder {
+func newNodeBuilder(clusters *clusterSet) *nodeBuilder {
 	return &nodeBuilder{
 		resourceType: nodeResourceType,
-		client:       c,
+		clusters:     clusters,
 	}
 }
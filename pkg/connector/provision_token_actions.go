@@ -0,0 +1,81 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+
+	config "github.com/conductorone/baton-sdk/pb/c1/config/v1"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/actions"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/gravitational/trace"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	deleteProvisionTokenAction = "delete_provision_token"
+
+	provisionTokenActionResourceKey = "resource_id"
+	provisionTokenActionNameKey     = "token_name"
+)
+
+// ResourceActions registers the action deleting a join token, so that a
+// stale token can no longer be used to enroll hosts or bots. Hosts that
+// already joined keep their identity.
+func (p *provisionTokenBuilder) ResourceActions(ctx context.Context, registry actions.ActionRegistry) error {
+	return registry.Register(ctx, v2.BatonActionSchema_builder{
+		Name:        deleteProvisionTokenAction,
+		DisplayName: "Delete provision token",
+		Description: "Delete a Teleport join token so it can no longer be used to join the cluster.",
+		Arguments: []*config.Field{
+			config.Field_builder{
+				Name:            provisionTokenActionResourceKey,
+				DisplayName:     "Provision token",
+				Description:     "The Teleport join token to delete.",
+				ResourceIdField: &config.ResourceIdField{},
+				IsRequired:      true,
+			}.Build(),
+		},
+		ReturnTypes: []*config.Field{
+			config.Field_builder{
+				Name:        "success",
+				DisplayName: "Success",
+				BoolField:   &config.BoolField{},
+			}.Build(),
+			config.Field_builder{
+				Name:        provisionTokenActionNameKey,
+				DisplayName: "Token name",
+				StringField: &config.StringField{},
+			}.Build(),
+		},
+		ActionType: []v2.ActionType{v2.ActionType_ACTION_TYPE_RESOURCE_DELETE},
+	}.Build(), p.deleteProvisionToken)
+}
+
+// deleteProvisionToken deletes the token. Static tokens are part of the Auth
+// Service configuration and cannot be deleted through the API. A token that
+// expires between the lookup and the delete counts as deleted.
+func (p *provisionTokenBuilder) deleteProvisionToken(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
+	resourceID, err := actions.RequireResourceIDArg(args, provisionTokenActionResourceKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: %w", err)
+	}
+	if resourceID.GetResourceType() != provisionTokenResourceType.Id || resourceID.GetResource() == "" {
+		return nil, nil, fmt.Errorf("baton-teleport: %s is not a provision token", resourceID.GetResource())
+	}
+
+	token, static, err := p.findProvisionToken(ctx, resourceID.GetResource())
+	if err != nil {
+		return nil, nil, err
+	}
+	displayName := provisionTokenDisplayName(token)
+	if static {
+		return nil, nil, fmt.Errorf("baton-teleport: provision token %s is static, remove it from the auth_service tokens in the Teleport configuration instead", displayName)
+	}
+
+	if err := p.client.DeleteToken(ctx, token.GetName()); err != nil && !trace.IsNotFound(err) {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to delete provision token %s: %w", displayName, err)
+	}
+
+	return actions.NewReturnValues(true, actions.NewStringReturnField(provisionTokenActionNameKey, displayName)), nil, nil
+}
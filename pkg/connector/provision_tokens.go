@@ -0,0 +1,273 @@
+package connector
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/trace"
+
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/conductorone/baton-teleport/pkg/client"
+)
+
+const (
+	// longLivedTokenTTL is how far in the future a token may expire before
+	// it is flagged as long-lived.
+	longLivedTokenTTL = 24 * time.Hour
+
+	// provisionTokenIDPrefix marks the IDs of tokens whose name is the secret
+	// used to join, which are replaced by a digest of the name.
+	provisionTokenIDPrefix = "sha256:"
+)
+
+type provisionTokenBuilder struct {
+	resourceType *v2.ResourceType
+	client       *client.TeleportClient
+}
+
+func (p *provisionTokenBuilder) ResourceType(_ context.Context) *v2.ResourceType {
+	return p.resourceType
+}
+
+// isSecretToken reports whether the token name is itself the secret presented
+// to join, as it is for the token join method. Other join methods prove the
+// identity of the joining host and their names can be shown.
+func isSecretToken(token types.ProvisionToken) bool {
+	method := token.GetJoinMethod()
+	return method == types.JoinMethodToken || method == types.JoinMethodUnspecified
+}
+
+// provisionTokenID returns the resource ID of a token. Secret token names
+// never leave the connector: their ID is a digest of the name.
+func provisionTokenID(token types.ProvisionToken) string {
+	if !isSecretToken(token) {
+		return token.GetName()
+	}
+	sum := sha256.Sum256([]byte(token.GetName()))
+	return provisionTokenIDPrefix + hex.EncodeToString(sum[:])
+}
+
+// provisionTokenDisplayName returns the token name with secret names masked
+// but for their last quarter, or entirely when they are short, the way
+// Teleport shows them in its own logs.
+func provisionTokenDisplayName(token types.ProvisionToken) string {
+	name := token.GetName()
+	if !isSecretToken(token) {
+		return name
+	}
+
+	visible := 0
+	if len(name) >= 16 {
+		visible = len(name) / 4
+	}
+	return strings.Repeat("*", len(name)-visible) + name[len(name)-visible:]
+}
+
+// provisionTokenAllowRules returns the allow rules of the token's join
+// method, such as the AWS accounts or GitHub repositories allowed to join.
+// They are read from the JSON form of the spec, where the rules of every
+// join method but the AWS ones sit under a key named after the method.
+func provisionTokenAllowRules(token types.ProvisionToken) (interface{}, error) {
+	raw, err := json.Marshal(token)
+	if err != nil {
+		return nil, err
+	}
+
+	var resource struct {
+		Spec map[string]interface{} `json:"spec"`
+	}
+	if err := json.Unmarshal(raw, &resource); err != nil {
+		return nil, err
+	}
+
+	if section, ok := resource.Spec[string(token.GetJoinMethod())].(map[string]interface{}); ok {
+		return section["allow"], nil
+	}
+	return resource.Spec["allow"], nil
+}
+
+// isLongLivedToken reports whether the token is static, never expires or
+// stays valid for longer than longLivedTokenTTL.
+func isLongLivedToken(token types.ProvisionToken, static bool, now time.Time) bool {
+	expires := token.Expiry()
+	return static || expires.IsZero() || expires.Sub(now) > longLivedTokenTTL
+}
+
+// Create a new connector resource for a join token. The profile carries the
+// system roles a host or bot joining with the token receives, how it proves
+// its identity, and when the token expires. Static tokens and tokens that
+// stay valid for a long time are flagged as long-lived for reviewers.
+func getProvisionTokenResource(token types.ProvisionToken, static bool) (*v2.Resource, error) {
+	allowRules, err := provisionTokenAllowRules(token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read allow rules: %w", err)
+	}
+
+	longLived := isLongLivedToken(token, static, time.Now())
+	profile := map[string]interface{}{
+		"token_name":  provisionTokenDisplayName(token),
+		"roles":       stringsToInterfaces(token.GetRoles().StringSlice()),
+		"join_method": string(token.GetJoinMethod()),
+		"static":      static,
+		"long_lived":  longLived,
+	}
+	if botName := token.GetBotName(); botName != "" {
+		profile["bot_name"] = botName
+	}
+	if allowRules != nil {
+		profile["allow_rules"] = allowRules
+	}
+	if expires := token.Expiry(); !expires.IsZero() {
+		profile["expires"] = formatTime(expires)
+	}
+
+	var opts []rs.ResourceOption
+	switch {
+	case static:
+		opts = append(opts, rs.WithDescription("Long-lived static token from the Auth Service configuration"))
+	case longLived:
+		opts = append(opts, rs.WithDescription("Long-lived token"))
+	}
+
+	return rs.NewRoleResource(
+		provisionTokenDisplayName(token),
+		provisionTokenResourceType,
+		provisionTokenID(token),
+		[]rs.RoleTraitOption{
+			rs.WithRoleProfile(profile),
+		},
+		opts...,
+	)
+}
+
+// staticTokens returns the tokens set in the Auth Service configuration.
+func (p *provisionTokenBuilder) staticTokens(ctx context.Context) ([]types.ProvisionToken, error) {
+	static, err := p.client.GetStaticTokens(ctx)
+	if trace.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("baton-teleport: failed to get static tokens: %w", err)
+	}
+	return static.GetStaticTokens(), nil
+}
+
+// List returns the join tokens one page at a time as resource objects. The
+// static tokens come with the first page.
+func (p *provisionTokenBuilder) List(ctx context.Context, _ *v2.ResourceId, opts rs.SyncOpAttrs) ([]*v2.Resource, *rs.SyncOpResults, error) {
+	var rv []*v2.Resource
+	if opts.PageToken.Token == "" {
+		static, err := p.staticTokens(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, token := range static {
+			tr, err := getProvisionTokenResource(token, true)
+			if err != nil {
+				return nil, nil, fmt.Errorf("baton-teleport: failed to create provision token resource: %w", err)
+			}
+			rv = append(rv, tr)
+		}
+	}
+
+	tokens, nextToken, err := p.client.GetProvisionTokensPage(ctx, &pagination.Token{Token: opts.PageToken.Token, Size: opts.PageToken.Size})
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to list provision tokens: %w", err)
+	}
+
+	for _, token := range tokens {
+		// Listed with the static tokens already.
+		if token.IsStatic() {
+			continue
+		}
+		tr, err := getProvisionTokenResource(token, false)
+		if err != nil {
+			return nil, nil, fmt.Errorf("baton-teleport: failed to create provision token resource: %w", err)
+		}
+		rv = append(rv, tr)
+	}
+
+	return rv, &rs.SyncOpResults{NextPageToken: nextToken}, nil
+}
+
+// findProvisionToken returns the token with the given resource ID and
+// whether it is static. Tokens identified by a digest can only be found by
+// listing them.
+func (p *provisionTokenBuilder) findProvisionToken(ctx context.Context, id string) (types.ProvisionToken, bool, error) {
+	if !strings.HasPrefix(id, provisionTokenIDPrefix) {
+		token, err := p.client.GetToken(ctx, id)
+		if err != nil {
+			return nil, false, fmt.Errorf("baton-teleport: failed to get provision token %s: %w", id, err)
+		}
+		return token, token.IsStatic(), nil
+	}
+
+	static, err := p.staticTokens(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, token := range static {
+		if provisionTokenID(token) == id {
+			return token, true, nil
+		}
+	}
+
+	page := &pagination.Token{}
+	for {
+		tokens, next, err := p.client.GetProvisionTokensPage(ctx, page)
+		if err != nil {
+			return nil, false, fmt.Errorf("baton-teleport: failed to list provision tokens: %w", err)
+		}
+		for _, token := range tokens {
+			if provisionTokenID(token) == id {
+				return token, token.IsStatic(), nil
+			}
+		}
+		if next == "" {
+			return nil, false, fmt.Errorf("baton-teleport: provision token %s not found", id)
+		}
+		page = &pagination.Token{Token: next}
+	}
+}
+
+func (p *provisionTokenBuilder) Get(ctx context.Context, resourceId *v2.ResourceId, _ *v2.ResourceId) (*v2.Resource, annotations.Annotations, error) {
+	if resourceId == nil {
+		return nil, nil, fmt.Errorf("baton-teleport: resourceId is required")
+	}
+
+	token, static, err := p.findProvisionToken(ctx, resourceId.Resource)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tr, err := getProvisionTokenResource(token, static)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to create provision token resource: %w", err)
+	}
+
+	return tr, nil, nil
+}
+
+func (p *provisionTokenBuilder) Entitlements(_ context.Context, _ *v2.Resource, _ rs.SyncOpAttrs) ([]*v2.Entitlement, *rs.SyncOpResults, error) {
+	return nil, nil, nil
+}
+
+func (p *provisionTokenBuilder) Grants(_ context.Context, _ *v2.Resource, _ rs.SyncOpAttrs) ([]*v2.Grant, *rs.SyncOpResults, error) {
+	return nil, nil, nil
+}
+
+func newProvisionTokenBuilder(c *client.TeleportClient) *provisionTokenBuilder {
+	return &provisionTokenBuilder{
+		resourceType: provisionTokenResourceType,
+		client:       c,
+	}
+}
@@ -0,0 +1,70 @@
+package connector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gravitational/teleport/api/types"
+	"github.com/stretchr/testify/require"
+
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+func TestGetProvisionTokenResource(t *testing.T) {
+	secret, err := types.NewProvisionToken("0123456789abcdef0123", types.SystemRoles{types.RoleNode, types.RoleApp}, time.Time{})
+	require.NoError(t, err)
+
+	res, err := getProvisionTokenResource(secret, true)
+	require.NoError(t, err)
+	require.Equal(t, provisionTokenResourceType.Id, res.Id.ResourceType)
+	require.NotContains(t, res.Id.Resource, secret.GetName())
+	require.Equal(t, provisionTokenID(secret), res.Id.Resource)
+	require.Equal(t, "***************f0123", res.DisplayName)
+
+	trait, err := rs.GetRoleTrait(res)
+	require.NoError(t, err)
+	profile := trait.GetProfile().AsMap()
+	require.Equal(t, []interface{}{"Node", "App"}, profile["roles"])
+	require.Equal(t, "token", profile["join_method"])
+	require.Equal(t, true, profile["static"])
+	require.Equal(t, true, profile["long_lived"])
+	require.NotContains(t, profile, "expires")
+
+	expires := time.Now().Add(time.Hour).UTC()
+	github, err := types.NewProvisionTokenFromSpec("gha-deploy", expires, types.ProvisionTokenSpecV2{
+		Roles:      types.SystemRoles{types.RoleBot},
+		JoinMethod: types.JoinMethodGitHub,
+		BotName:    "deploy",
+		GitHub: &types.ProvisionTokenSpecV2GitHub{
+			Allow: []*types.ProvisionTokenSpecV2GitHub_Rule{{Repository: "acme/infra"}},
+		},
+	})
+	require.NoError(t, err)
+
+	res, err = getProvisionTokenResource(github, false)
+	require.NoError(t, err)
+	require.Equal(t, "gha-deploy", res.Id.Resource)
+	require.Equal(t, "gha-deploy", res.DisplayName)
+
+	trait, err = rs.GetRoleTrait(res)
+	require.NoError(t, err)
+	profile = trait.GetProfile().AsMap()
+	require.Equal(t, "deploy", profile["bot_name"])
+	require.Equal(t, false, profile["long_lived"])
+	require.Equal(t, []interface{}{map[string]interface{}{"repository": "acme/infra"}}, profile["allow_rules"])
+	require.Equal(t, formatTime(expires), profile["expires"])
+}
+
+func TestIsLongLivedToken(t *testing.T) {
+	now := time.Now()
+	token := func(expires time.Time) types.ProvisionToken {
+		rv, err := types.NewProvisionToken("0123456789abcdef0123", types.SystemRoles{types.RoleNode}, expires)
+		require.NoError(t, err)
+		return rv
+	}
+
+	require.False(t, isLongLivedToken(token(now.Add(time.Hour)), false, now))
+	require.True(t, isLongLivedToken(token(now.Add(30*24*time.Hour)), false, now))
+	require.True(t, isLongLivedToken(token(time.Time{}), false, now))
+	require.True(t, isLongLivedToken(token(now.Add(time.Hour)), true, now))
+}
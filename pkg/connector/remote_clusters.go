@@ -0,0 +1,95 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/gravitational/teleport/api/types"
+
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/conductorone/baton-teleport/pkg/client"
+)
+
+type remoteClusterBuilder struct {
+	resourceType *v2.ResourceType
+	client       *client.TeleportClient
+}
+
+func (r *remoteClusterBuilder) ResourceType(_ context.Context) *v2.ResourceType {
+	return r.resourceType
+}
+
+// Create a new connector resource for a remote cluster, a leaf cluster that
+// trusts this (root) cluster. The profile carries whether its reverse tunnel
+// is connected and when it last reported.
+func getRemoteClusterResource(cluster types.RemoteCluster) (*v2.Resource, error) {
+	return rs.NewRoleResource(
+		cluster.GetName(),
+		remoteClusterResourceType,
+		cluster.GetName(),
+		[]rs.RoleTraitOption{
+			rs.WithRoleProfile(map[string]interface{}{
+				"cluster_name":      cluster.GetName(),
+				"connection_status": cluster.GetConnectionStatus(),
+				"last_heartbeat":    formatTime(cluster.GetLastHeartbeat()),
+				labelsProfileKey:    labelsProfile(cluster.GetAllLabels()),
+			}),
+		},
+	)
+}
+
+// List returns the remote clusters of this cluster one page at a time as
+// resource objects.
+func (r *remoteClusterBuilder) List(ctx context.Context, _ *v2.ResourceId, opts rs.SyncOpAttrs) ([]*v2.Resource, *rs.SyncOpResults, error) {
+	var rv []*v2.Resource
+	clusters, nextToken, err := r.client.GetRemoteClustersPage(ctx, &pagination.Token{Token: opts.PageToken.Token, Size: opts.PageToken.Size})
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to list remote clusters: %w", err)
+	}
+
+	for _, cluster := range clusters {
+		cr, err := getRemoteClusterResource(cluster)
+		if err != nil {
+			return nil, nil, fmt.Errorf("baton-teleport: failed to create remote cluster resource: %w", err)
+		}
+		rv = append(rv, cr)
+	}
+
+	return rv, &rs.SyncOpResults{NextPageToken: nextToken}, nil
+}
+
+func (r *remoteClusterBuilder) Get(ctx context.Context, resourceId *v2.ResourceId, _ *v2.ResourceId) (*v2.Resource, annotations.Annotations, error) {
+	if resourceId == nil {
+		return nil, nil, fmt.Errorf("baton-teleport: resourceId is required")
+	}
+
+	cluster, err := r.client.GetRemoteCluster(ctx, resourceId.Resource)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to get remote cluster %s: %w", resourceId.Resource, err)
+	}
+
+	cr, err := getRemoteClusterResource(cluster)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to create remote cluster resource: %w", err)
+	}
+
+	return cr, nil, nil
+}
+
+func (r *remoteClusterBuilder) Entitlements(_ context.Context, _ *v2.Resource, _ rs.SyncOpAttrs) ([]*v2.Entitlement, *rs.SyncOpResults, error) {
+	return nil, nil, nil
+}
+
+func (r *remoteClusterBuilder) Grants(_ context.Context, _ *v2.Resource, _ rs.SyncOpAttrs) ([]*v2.Grant, *rs.SyncOpResults, error) {
+	return nil, nil, nil
+}
+
+func newRemoteClusterBuilder(c *client.TeleportClient) *remoteClusterBuilder {
+	return &remoteClusterBuilder{
+		resourceType: remoteClusterResourceType,
+		client:       c,
+	}
+}
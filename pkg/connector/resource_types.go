@@ -32,4 +32,67 @@ var (
 		Id:          "database",
 		DisplayName: "Database",
 	}
+	kubeClusterResourceType = &v2.ResourceType{
+		Id:          "kube_cluster",
+		DisplayName: "Kubernetes Cluster",
+	}
+	windowsDesktopResourceType = &v2.ResourceType{
+		Id:          "windows_desktop",
+		DisplayName: "Windows Desktop",
+	}
+	botResourceType = &v2.ResourceType{
+		Id:          "bot",
+		DisplayName: "Bot",
+		Traits:      []v2.ResourceType_Trait{v2.ResourceType_TRAIT_USER},
+		Annotations: annotations.New(&v2.SkipEntitlementsAndGrants{}),
+	}
+	lockResourceType = &v2.ResourceType{
+		Id:          "lock",
+		DisplayName: "Lock",
+		Annotations: annotations.New(&v2.SkipEntitlementsAndGrants{}),
+	}
+	samlConnectorResourceType = &v2.ResourceType{
+		Id:          "saml_connector",
+		DisplayName: "SAML Connector",
+		Annotations: annotations.New(&v2.SkipEntitlementsAndGrants{}),
+	}
+	oidcConnectorResourceType = &v2.ResourceType{
+		Id:          "oidc_connector",
+		DisplayName: "OIDC Connector",
+		Annotations: annotations.New(&v2.SkipEntitlementsAndGrants{}),
+	}
+	githubConnectorResourceType = &v2.ResourceType{
+		Id:          "github_connector",
+		DisplayName: "GitHub Connector",
+		Annotations: annotations.New(&v2.SkipEntitlementsAndGrants{}),
+	}
+	ssoGroupResourceType = &v2.ResourceType{
+		Id:          "sso_group",
+		DisplayName: "SSO Group",
+		Traits:      []v2.ResourceType_Trait{v2.ResourceType_TRAIT_GROUP},
+	}
+	trustedClusterResourceType = &v2.ResourceType{
+		Id:          "trusted_cluster",
+		DisplayName: "Trusted Cluster",
+	}
+	remoteClusterResourceType = &v2.ResourceType{
+		Id:          "remote_cluster",
+		DisplayName: "Remote Cluster",
+		Annotations: annotations.New(&v2.SkipEntitlementsAndGrants{}),
+	}
+	accessListResourceType = &v2.ResourceType{
+		Id:          "access_list",
+		DisplayName: "Access List",
+		Traits:      []v2.ResourceType_Trait{v2.ResourceType_TRAIT_GROUP},
+	}
+	clusterResourceType = &v2.ResourceType{
+		Id:          "cluster",
+		DisplayName: "Cluster",
+		Annotations: annotations.New(&v2.SkipEntitlementsAndGrants{}),
+	}
+	provisionTokenResourceType = &v2.ResourceType{
+		Id:          "provision_token",
+		DisplayName: "Provision Token",
+		Annotations: annotations.New(&v2.SkipEntitlementsAndGrants{}),
+	}
 )
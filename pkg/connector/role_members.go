@@ -0,0 +1,123 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	machineidv1 "github.com/gravitational/teleport/api/gen/proto/go/teleport/machineid/v1"
+	"github.com/gravitational/teleport/api/types"
+)
+
+// roleGrantsPageSize is the number of members returned per page of a role's
+// grants when the sync does not ask for a size.
+const roleGrantsPageSize = 100
+
+// roleMember is a user or bot holding a role. Users carry the sources of the
+// role as grant metadata, and their name and traits to resolve the role's
+// trait templates; bots have none.
+type roleMember struct {
+	principal *v2.ResourceId
+	sources   []string
+	name      string
+	traits    map[string][]string
+}
+
+// roleMemberIndex maps a role name to the principals holding it, in the
+// order they were listed.
+type roleMemberIndex map[string][]roleMember
+
+// loadRoleMemberIndex builds the index for the whole cluster. Users are read
+// one page at a time, and only their names, traits and role sources are kept. Bots
+// are only synced for the root cluster.
+func loadRoleMemberIndex(ctx context.Context, scope *clusterScope) (roleMemberIndex, error) {
+	sources, err := loadRoleSources(ctx, scope.client)
+	if err != nil {
+		return nil, err
+	}
+
+	index := roleMemberIndex{}
+	err = scope.client.ForEachUser(ctx, func(user types.User) error {
+		principal := &v2.ResourceId{ResourceType: userResourceType.Id, Resource: scope.resourceID(user.GetName())}
+		index.addUser(principal, user, sources)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("baton-teleport: failed to list users: %w", err)
+	}
+
+	if scope.leaf {
+		return index, nil
+	}
+
+	bots, err := scope.client.GetAllBots(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("baton-teleport: failed to list bots: %w", err)
+	}
+	for _, bot := range bots {
+		index.addBot(bot)
+	}
+
+	return index, nil
+}
+
+// addUser records the user, as principal, as a member of each of its
+// effective roles.
+func (idx roleMemberIndex) addUser(principal *v2.ResourceId, user types.User, sources *roleSources) {
+	for _, role := range sources.effectiveRoles(user) {
+		idx[role] = append(idx[role], roleMember{
+			principal: principal,
+			sources:   sources.grantSources(user, role),
+			name:      user.GetName(),
+			traits:    user.GetTraits(),
+		})
+	}
+}
+
+// addBot records the bot as a member of each role it can assume.
+func (idx roleMemberIndex) addBot(bot *machineidv1.Bot) {
+	principal := &v2.ResourceId{ResourceType: botResourceType.Id, Resource: bot.GetMetadata().GetName()}
+	for _, role := range bot.GetSpec().GetRoles() {
+		idx[role] = append(idx[role], roleMember{principal: principal})
+	}
+}
+
+// users returns the users holding the role.
+func (idx roleMemberIndex) users(role string) []roleMember {
+	var rv []roleMember
+	for _, member := range idx[role] {
+		if member.principal.ResourceType == userResourceType.Id {
+			rv = append(rv, member)
+		}
+	}
+	return rv
+}
+
+// page returns the members of the role starting at the offset encoded in
+// token, and the token of the next page, which is empty on the last page.
+func (idx roleMemberIndex) page(role, token string, size int) ([]roleMember, string, error) {
+	offset := 0
+	if token != "" {
+		var err error
+		offset, err = strconv.Atoi(token)
+		if err != nil || offset < 0 {
+			return nil, "", fmt.Errorf("baton-teleport: invalid role grants page token %q", token)
+		}
+	}
+	if size <= 0 {
+		size = roleGrantsPageSize
+	}
+
+	members := idx[role]
+	if offset >= len(members) {
+		return nil, "", nil
+	}
+
+	end := min(offset+size, len(members))
+	next := ""
+	if end < len(members) {
+		next = strconv.Itoa(end)
+	}
+	return members[offset:end], next, nil
+}
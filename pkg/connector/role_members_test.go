@@ -0,0 +1,70 @@
+package connector
+
+import (
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	headerv1 "github.com/gravitational/teleport/api/gen/proto/go/teleport/header/v1"
+	machineidv1 "github.com/gravitational/teleport/api/gen/proto/go/teleport/machineid/v1"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/api/types/header"
+	"github.com/gravitational/teleport/api/types/userloginstate"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoleMemberIndex(t *testing.T) {
+	state, err := userloginstate.New(header.Metadata{Name: "bob"}, userloginstate.Spec{
+		Roles:           []string{"dev"},
+		AccessListRoles: []string{"dev"},
+	})
+	require.NoError(t, err)
+
+	sources := &roleSources{
+		loginStates:     map[string]*userloginstate.UserLoginState{"bob": state},
+		accessListRoles: map[string]map[string][]string{"bob": {"dev": {"engineering"}}},
+	}
+
+	index := roleMemberIndex{}
+	for _, name := range []string{"alice", "bob", "carol"} {
+		user, err := types.NewUser(name)
+		require.NoError(t, err)
+		user.SetRoles([]string{"access"})
+		index.addUser(&v2.ResourceId{ResourceType: userResourceType.Id, Resource: name}, user, sources)
+	}
+	index.addBot(&machineidv1.Bot{
+		Metadata: &headerv1.Metadata{Name: "ci"},
+		Spec:     &machineidv1.BotSpec{Roles: []string{"dev"}},
+	})
+
+	dev, next, err := index.page("dev", "", 0)
+	require.NoError(t, err)
+	require.Empty(t, next)
+	require.Len(t, dev, 2)
+	require.Equal(t, "bob", dev[0].principal.Resource)
+	require.Equal(t, []string{"access_list:engineering"}, dev[0].sources)
+	require.Equal(t, botResourceType.Id, dev[1].principal.ResourceType)
+	require.Empty(t, dev[1].sources)
+
+	var names []string
+	token := ""
+	for {
+		members, next, err := index.page("access", token, 2)
+		require.NoError(t, err)
+		for _, member := range members {
+			names = append(names, member.principal.Resource)
+		}
+		if next == "" {
+			break
+		}
+		token = next
+	}
+	require.Equal(t, []string{"alice", "bob", "carol"}, names)
+
+	missing, next, err := index.page("missing", "", 0)
+	require.NoError(t, err)
+	require.Empty(t, missing)
+	require.Empty(t, next)
+
+	_, _, err = index.page("access", "bogus", 0)
+	require.Error(t, err)
+}
@@ -0,0 +1,147 @@
+package connector
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/gravitational/teleport/api/types"
+
+	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+)
+
+// rolePolicyProfile renders the allow and deny conditions and the options of
+// a role as structured profile values.
+func rolePolicyProfile(role types.Role) map[string]interface{} {
+	options := role.GetOptions()
+	return map[string]interface{}{
+		"allow": roleConditionsProfile(role, types.Allow),
+		"deny":  roleConditionsProfile(role, types.Deny),
+		"options": map[string]interface{}{
+			"max_session_ttl": time.Duration(options.MaxSessionTTL).String(),
+			"require_mfa":     options.RequireMFAType.String(),
+			"lock":            string(options.Lock),
+		},
+	}
+}
+
+func roleConditionsProfile(role types.Role, condition types.RoleConditionType) map[string]interface{} {
+	rules := make([]interface{}, 0, len(role.GetRules(condition)))
+	for _, rule := range role.GetRules(condition) {
+		r := map[string]interface{}{
+			"resources": stringsToInterfaces(rule.Resources),
+			"verbs":     stringsToInterfaces(rule.Verbs),
+		}
+		if rule.Where != "" {
+			r["where"] = rule.Where
+		}
+		rules = append(rules, r)
+	}
+
+	return map[string]interface{}{
+		"logins":                 stringsToInterfaces(role.GetLogins(condition)),
+		"windows_desktop_logins": stringsToInterfaces(role.GetWindowsLogins(condition)),
+		"kubernetes_groups":      stringsToInterfaces(role.GetKubeGroups(condition)),
+		"kubernetes_users":       stringsToInterfaces(role.GetKubeUsers(condition)),
+		"db_users":               stringsToInterfaces(role.GetDatabaseUsers(condition)),
+		"db_names":               stringsToInterfaces(role.GetDatabaseNames(condition)),
+		"node_labels":            selectorProfile(role.GetNodeLabels(condition)),
+		"app_labels":             selectorProfile(role.GetAppLabels(condition)),
+		"db_labels":              selectorProfile(role.GetDatabaseLabels(condition)),
+		"kubernetes_labels":      selectorProfile(role.GetKubernetesLabels(condition)),
+		"windows_desktop_labels": selectorProfile(role.GetWindowsDesktopLabels(condition)),
+		"rules":                  rules,
+	}
+}
+
+func selectorProfile(labels types.Labels) map[string]interface{} {
+	rv := make(map[string]interface{}, len(labels))
+	for key, values := range labels {
+		rv[key] = stringsToInterfaces(values)
+	}
+	return rv
+}
+
+// ruleVerb is one verb a role allows on one kind of Teleport resource.
+type ruleVerb struct {
+	kind        string
+	verb        string
+	conditional bool
+}
+
+func (v ruleVerb) slug() string {
+	return v.kind + ":" + v.verb
+}
+
+// roleRuleVerbs returns every kind and verb pair the role's allow rules
+// grant that no unconditional deny rule takes away, sorted by slug. A pair
+// only allowed under a where clause is marked conditional.
+func roleRuleVerbs(role types.Role) []ruleVerb {
+	denied := func(kind, verb string) bool {
+		for _, rule := range role.GetRules(types.Deny) {
+			if rule.Where != "" {
+				continue
+			}
+			if (slices.Contains(rule.Resources, kind) || slices.Contains(rule.Resources, types.Wildcard)) &&
+				(slices.Contains(rule.Verbs, verb) || slices.Contains(rule.Verbs, types.Wildcard)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	verbs := map[string]ruleVerb{}
+	for _, rule := range role.GetRules(types.Allow) {
+		for _, kind := range rule.Resources {
+			for _, verb := range rule.Verbs {
+				if denied(kind, verb) {
+					continue
+				}
+				v := ruleVerb{kind: kind, verb: verb, conditional: rule.Where != ""}
+				if existing, ok := verbs[v.slug()]; ok && !existing.conditional {
+					continue
+				}
+				verbs[v.slug()] = v
+			}
+		}
+	}
+
+	rv := make([]ruleVerb, 0, len(verbs))
+	for _, v := range verbs {
+		rv = append(rv, v)
+	}
+	sort.Slice(rv, func(i, j int) bool { return rv[i].slug() < rv[j].slug() })
+	return rv
+}
+
+// roleRuleEntitlements returns one entitlement per resource rule verb the
+// role allows, such as user:create.
+func roleRuleEntitlements(resource *v2.Resource, verbs []ruleVerb) []*v2.Entitlement {
+	rv := make([]*v2.Entitlement, 0, len(verbs))
+	for _, v := range verbs {
+		description := fmt.Sprintf("Allowed to %s %s resources through the %s Teleport role", v.verb, v.kind, resource.DisplayName)
+		if v.conditional {
+			description += " when its where condition matches"
+		}
+		rv = append(rv, ent.NewPermissionEntitlement(
+			resource,
+			v.slug(),
+			ent.WithGrantableTo(roleResourceType),
+			ent.WithDisplayName(fmt.Sprintf("%s Role %s", resource.DisplayName, v.slug())),
+			ent.WithDescription(description),
+		))
+	}
+	return rv
+}
+
+// roleRuleGrants grants each rule verb entitlement of a role to the role
+// itself, expanded to its members.
+func roleRuleGrants(resource *v2.Resource, verbs []ruleVerb) []*v2.Grant {
+	rv := make([]*v2.Grant, 0, len(verbs))
+	for _, v := range verbs {
+		rv = append(rv, newRoleGrant(resource, v.slug(), resource.Id.Resource))
+	}
+	return rv
+}
@@ -0,0 +1,83 @@
+package connector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gravitational/teleport/api/constants"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/stretchr/testify/require"
+
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+func TestGetRoleResource_PolicyProfile(t *testing.T) {
+	role, err := types.NewRole("auditor", types.RoleSpecV6{
+		Options: types.RoleOptions{
+			MaxSessionTTL:  types.NewDuration(8 * time.Hour),
+			RequireMFAType: types.RequireMFAType_SESSION,
+			Lock:           constants.LockingModeStrict,
+		},
+		Allow: types.RoleConditions{
+			Logins:     []string{"ubuntu"},
+			NodeLabels: types.Labels{"env": {"prod"}},
+			Rules:      []types.Rule{{Resources: []string{"event"}, Verbs: []string{"list", "read"}}},
+		},
+		Deny: types.RoleConditions{
+			Logins: []string{"root"},
+		},
+	})
+	require.NoError(t, err)
+
+	res, err := getRoleResource(role)
+	require.NoError(t, err)
+
+	trait, err := rs.GetRoleTrait(res)
+	require.NoError(t, err)
+	profile := trait.GetProfile().AsMap()
+
+	require.Equal(t, "auditor", profile["role_name"])
+
+	allow := profile["allow"].(map[string]interface{})
+	require.Equal(t, []interface{}{"ubuntu"}, allow["logins"])
+	require.Equal(t, map[string]interface{}{"env": []interface{}{"prod"}}, allow["node_labels"])
+	require.Equal(t, []interface{}{map[string]interface{}{
+		"resources": []interface{}{"event"},
+		"verbs":     []interface{}{"list", "read"},
+	}}, allow["rules"])
+
+	deny := profile["deny"].(map[string]interface{})
+	require.Equal(t, []interface{}{"root"}, deny["logins"])
+
+	options := profile["options"].(map[string]interface{})
+	require.Equal(t, "8h0m0s", options["max_session_ttl"])
+	require.Equal(t, "SESSION", options["require_mfa"])
+	require.Equal(t, "strict", options["lock"])
+}
+
+func TestRoleRuleVerbs(t *testing.T) {
+	role, err := types.NewRole("user-admin", types.RoleSpecV6{
+		Allow: types.RoleConditions{
+			Rules: []types.Rule{
+				{Resources: []string{"user"}, Verbs: []string{"create", "delete", "read"}},
+				{Resources: []string{"role"}, Verbs: []string{"update"}, Where: `contains(user.spec.traits["team"], "iam")`},
+			},
+		},
+		Deny: types.RoleConditions{
+			Rules: []types.Rule{{Resources: []string{"user"}, Verbs: []string{"delete"}}},
+		},
+	})
+	require.NoError(t, err)
+
+	var slugs []string
+	var conditional []string
+	for _, v := range roleRuleVerbs(role) {
+		slugs = append(slugs, v.slug())
+		if v.conditional {
+			conditional = append(conditional, v.slug())
+		}
+	}
+
+	require.Equal(t, []string{"role:update", "user:create", "user:read"}, slugs)
+	require.Equal(t, []string{"role:update"}, conditional)
+}
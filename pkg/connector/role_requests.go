@@ -0,0 +1,99 @@
+package connector
+
+import (
+	"fmt"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/gravitational/teleport/api/types"
+
+	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+)
+
+const (
+	roleCanRequest = "can_request"
+	roleCanReview  = "can_review"
+	roleViaKey     = "via"
+)
+
+// roleRelationField is one role condition naming the roles a role relates
+// to, such as allow.request.roles.
+type roleRelationField struct {
+	name  string
+	roles func(types.Role, types.RoleConditionType) []string
+}
+
+// roleRelation models one way a role reaches another through access
+// requests as an entitlement on the target role, held by the source role.
+type roleRelation struct {
+	slug        string
+	description string
+	fields      []roleRelationField
+}
+
+var roleRelations = []roleRelation{
+	{
+		slug:        roleCanRequest,
+		description: "Roles that can request the %s Teleport role",
+		fields: []roleRelationField{
+			{name: "roles", roles: func(r types.Role, c types.RoleConditionType) []string {
+				return r.GetAccessRequestConditions(c).Roles
+			}},
+			{name: "search_as_roles", roles: types.Role.GetSearchAsRoles},
+		},
+	},
+	{
+		slug:        roleCanReview,
+		description: "Roles that can review access requests for the %s Teleport role",
+		fields: []roleRelationField{
+			{name: "roles", roles: func(r types.Role, c types.RoleConditionType) []string {
+				return r.GetAccessReviewConditions(c).Roles
+			}},
+		},
+	},
+}
+
+// roleRelationEntitlements returns the can_request and can_review
+// entitlements of a role.
+func roleRelationEntitlements(resource *v2.Resource) []*v2.Entitlement {
+	rv := make([]*v2.Entitlement, 0, len(roleRelations))
+	for _, relation := range roleRelations {
+		rv = append(rv, ent.NewPermissionEntitlement(
+			resource,
+			relation.slug,
+			ent.WithGrantableTo(roleResourceType),
+			ent.WithDisplayName(fmt.Sprintf("%s Role %s", resource.DisplayName, relation.slug)),
+			ent.WithDescription(fmt.Sprintf(relation.description, resource.DisplayName)),
+		))
+	}
+	return rv
+}
+
+// roleRelationGrants returns a grant of each relation on the target role to
+// every role whose allow conditions name it, as a literal, glob or regular
+// expression, and whose deny conditions do not. The grants expand to the
+// members of the source role and record which conditions matched. Source
+// roles are looked up in the cluster of the target.
+func roleRelationGrants(resource *v2.Resource, scope *clusterScope, roles []types.Role) []*v2.Grant {
+	var rv []*v2.Grant
+	target := scope.teleportName(resource.Id.Resource)
+	for _, relation := range roleRelations {
+		for _, role := range roles {
+			var via []string
+			for _, field := range relation.fields {
+				if matchLabelValues(field.roles(role, types.Allow), target) &&
+					!matchLabelValues(field.roles(role, types.Deny), target) {
+					via = append(via, field.name)
+				}
+			}
+			if len(via) == 0 {
+				continue
+			}
+
+			rv = append(rv, newRoleGrant(resource, relation.slug, scope.resourceID(role.GetName()), grant.WithGrantMetadata(map[string]interface{}{
+				roleViaKey: stringsToInterfaces(via),
+			})))
+		}
+	}
+	return rv
+}
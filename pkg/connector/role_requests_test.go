@@ -0,0 +1,65 @@
+package connector
+
+import (
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoleRelationGrants(t *testing.T) {
+	requester, err := types.NewRole("requester", types.RoleSpecV6{
+		Allow: types.RoleConditions{
+			Request: &types.AccessRequestConditions{
+				Roles:         []string{"db-*"},
+				SearchAsRoles: []string{"db-admin"},
+			},
+		},
+		Deny: types.RoleConditions{
+			Request: &types.AccessRequestConditions{Roles: []string{"db-root"}},
+		},
+	})
+	require.NoError(t, err)
+
+	reviewer, err := types.NewRole("reviewer", types.RoleSpecV6{
+		Allow: types.RoleConditions{
+			ReviewRequests: &types.AccessReviewConditions{Roles: []string{"^db-(admin|root)$"}},
+		},
+	})
+	require.NoError(t, err)
+
+	roles := []types.Role{requester, reviewer}
+	grantsFor := func(target string) map[string]*v2.Grant {
+		resource := &v2.Resource{Id: &v2.ResourceId{ResourceType: roleResourceType.Id, Resource: target}}
+		rv := map[string]*v2.Grant{}
+		for _, g := range roleRelationGrants(resource, nil, roles) {
+			rv[entitlementSlug(g.Entitlement)+"/"+g.Principal.Id.Resource] = g
+		}
+		return rv
+	}
+
+	admin := grantsFor("db-admin")
+	require.Len(t, admin, 2)
+	require.Contains(t, admin, "can_request/requester")
+	require.Contains(t, admin, "can_review/reviewer")
+	require.Equal(t, []interface{}{"roles", "search_as_roles"}, grantMetadata(t, admin["can_request/requester"])[roleViaKey])
+
+	root := grantsFor("db-root")
+	require.Len(t, root, 1)
+	require.Contains(t, root, "can_review/reviewer")
+
+	require.Empty(t, grantsFor("web"))
+}
+
+func grantMetadata(t *testing.T, g *v2.Grant) map[string]interface{} {
+	t.Helper()
+	for _, a := range g.Annotations {
+		metadata := &v2.GrantMetadata{}
+		if a.MessageIs(metadata) {
+			require.NoError(t, a.UnmarshalTo(metadata))
+			return metadata.GetMetadata().AsMap()
+		}
+	}
+	return nil
+}
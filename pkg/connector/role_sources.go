@@ -0,0 +1,194 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/api/types/accesslist"
+	"github.com/gravitational/teleport/api/types/userloginstate"
+	"github.com/gravitational/trace"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+
+	"github.com/conductorone/baton-teleport/pkg/client"
+)
+
+const (
+	roleSourcesKey         = "sources"
+	roleSourceStatic       = "static"
+	roleSourceSSOConnector = "sso_connector"
+	roleSourceAccessList   = "access_list"
+)
+
+// roleSources records where a user's effective roles come from: the user
+// resource itself, the SSO connector that created it, or Access Lists
+// applied through the user's login state.
+type roleSources struct {
+	// loginStates holds the login state of each user that has one, keyed by
+	// user name.
+	loginStates map[string]*userloginstate.UserLoginState
+	// accessListRoles maps a user name to the Access Lists that grant each
+	// role to that user.
+	accessListRoles map[string]map[string][]string
+}
+
+// loadRoleSources reads every user login state and Access List. Clusters
+// without Identity Governance, or a connector identity that cannot read
+// these resources, only report static and SSO roles.
+func loadRoleSources(ctx context.Context, c *client.TeleportClient) (*roleSources, error) {
+	l := ctxzap.Extract(ctx)
+	sources := &roleSources{
+		loginStates:     map[string]*userloginstate.UserLoginState{},
+		accessListRoles: map[string]map[string][]string{},
+	}
+
+	states, err := c.GetAllUserLoginStates(ctx)
+	if err != nil {
+		if isUnavailable(err) {
+			l.Warn("baton-teleport: user login states are unavailable, only static role grants are synced", zap.Error(err))
+			return sources, nil
+		}
+		return nil, fmt.Errorf("baton-teleport: failed to list user login states: %w", err)
+	}
+	for _, state := range states {
+		sources.loginStates[state.GetName()] = state
+	}
+
+	lists, err := c.GetAllAccessLists(ctx)
+	if err != nil {
+		if isUnavailable(err) {
+			l.Warn("baton-teleport: access lists are unavailable, access list role grants are not attributed", zap.Error(err))
+			return sources, nil
+		}
+		return nil, fmt.Errorf("baton-teleport: failed to list access lists: %w", err)
+	}
+
+	members := make(map[string][]*accesslist.AccessListMember, len(lists))
+	for _, list := range lists {
+		listMembers, err := c.GetAllAccessListMembers(ctx, list.GetName())
+		if err != nil {
+			return nil, fmt.Errorf("baton-teleport: failed to list members of access list %s: %w", list.GetName(), err)
+		}
+		members[list.GetName()] = listMembers
+	}
+	sources.accessListRoles = accessListRoleIndex(lists, members)
+
+	return sources, nil
+}
+
+// isUnavailable reports whether err means the feature is not available to
+// the connector rather than that the request failed.
+func isUnavailable(err error) bool {
+	return trace.IsNotImplemented(err) || trace.IsAccessDenied(err)
+}
+
+// accessListRoleIndex maps each user to the Access Lists granting them each
+// role. Members of a nested list inherit the grants of the lists it belongs
+// to, and the users of a list that owns another list receive its owner
+// grants. Members whose membership has expired grant nothing.
+func accessListRoleIndex(lists []*accesslist.AccessList, members map[string][]*accesslist.AccessListMember) map[string]map[string][]string {
+	now := time.Now()
+	resolved := map[string][]string{}
+	var usersOf func(listName string, visiting map[string]bool) []string
+	usersOf = func(listName string, visiting map[string]bool) []string {
+		if users, ok := resolved[listName]; ok {
+			return users
+		}
+		if visiting[listName] {
+			return nil
+		}
+		visiting[listName] = true
+
+		var users []string
+		for _, member := range members[listName] {
+			if expires := member.Spec.Expires; !expires.IsZero() && !expires.After(now) {
+				continue
+			}
+			if member.IsUser() {
+				users = appendUnique(users, member.Spec.Name)
+				continue
+			}
+			for _, user := range usersOf(member.Spec.Name, visiting) {
+				users = appendUnique(users, user)
+			}
+		}
+
+		resolved[listName] = users
+		return users
+	}
+
+	index := map[string]map[string][]string{}
+	add := func(users, roles []string, listName string) {
+		for _, user := range users {
+			if index[user] == nil {
+				index[user] = map[string][]string{}
+			}
+			for _, role := range roles {
+				index[user][role] = appendUnique(index[user][role], listName)
+			}
+		}
+	}
+
+	for _, list := range lists {
+		add(usersOf(list.GetName(), map[string]bool{}), list.GetGrants().Roles, list.GetName())
+
+		var owners []string
+		for _, owner := range list.GetOwners() {
+			if owner.IsMembershipKindUser() {
+				owners = appendUnique(owners, owner.Name)
+				continue
+			}
+			for _, user := range usersOf(owner.Name, map[string]bool{}) {
+				owners = appendUnique(owners, user)
+			}
+		}
+		add(owners, list.GetOwnerGrants().Roles, list.GetName())
+	}
+
+	return index
+}
+
+// effectiveRoles returns the user's own roles plus the roles Access Lists
+// applied through its login state. The login state is only refreshed on
+// login, so it is not trusted for roles set on the user itself.
+func (s *roleSources) effectiveRoles(user types.User) []string {
+	roles := slices.Clone(user.GetRoles())
+	if state, ok := s.loginStates[user.GetName()]; ok {
+		for _, role := range state.GetAccessListRoles() {
+			roles = appendUnique(roles, role)
+		}
+	}
+	return roles
+}
+
+// grantSources returns where the user's role comes from: static for a role
+// set on a local user, sso_connector for a role mapped by the SSO connector
+// that created the user, and access_list:<name> for each Access List
+// granting it.
+func (s *roleSources) grantSources(user types.User, role string) []string {
+	var sources []string
+	if slices.Contains(user.GetRoles(), role) {
+		if user.GetUserType() == types.UserTypeSSO {
+			sources = append(sources, roleSourceSSOConnector)
+		} else {
+			sources = append(sources, roleSourceStatic)
+		}
+	}
+
+	for _, listName := range s.accessListRoles[user.GetName()][role] {
+		sources = append(sources, roleSourceAccessList+":"+listName)
+	}
+
+	// The login state knows a list applied the role even when the list
+	// itself could not be attributed.
+	if state, ok := s.loginStates[user.GetName()]; ok && len(s.accessListRoles[user.GetName()][role]) == 0 {
+		if slices.Contains(state.GetAccessListRoles(), role) {
+			sources = append(sources, roleSourceAccessList)
+		}
+	}
+
+	return sources
+}
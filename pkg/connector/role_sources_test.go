@@ -0,0 +1,103 @@
+package connector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/api/types/accesslist"
+	"github.com/gravitational/teleport/api/types/header"
+	"github.com/gravitational/teleport/api/types/userloginstate"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAccessList(t *testing.T, name string, owners []accesslist.Owner, grants, ownerGrants []string) *accesslist.AccessList {
+	t.Helper()
+	return &accesslist.AccessList{
+		ResourceHeader: header.ResourceHeader{Metadata: header.Metadata{Name: name}},
+		Spec: accesslist.Spec{
+			Owners:      owners,
+			Grants:      accesslist.Grants{Roles: grants},
+			OwnerGrants: accesslist.Grants{Roles: ownerGrants},
+		},
+	}
+}
+
+func newTestMember(t *testing.T, list, name, kind string) *accesslist.AccessListMember {
+	t.Helper()
+	member, err := accesslist.NewAccessListMember(
+		header.Metadata{Name: name},
+		accesslist.AccessListMemberSpec{AccessList: list, Name: name, MembershipKind: kind},
+	)
+	require.NoError(t, err)
+	return member
+}
+
+func TestAccessListRoleIndex_NestedListsAndOwners(t *testing.T) {
+	lists := []*accesslist.AccessList{
+		newTestAccessList(t, "engineering", []accesslist.Owner{{Name: "leads", MembershipKind: accesslist.MembershipKindList}}, []string{"dev"}, []string{"reviewer"}),
+		newTestAccessList(t, "platform", nil, []string{"ops"}, nil),
+		newTestAccessList(t, "leads", nil, nil, nil),
+	}
+	members := map[string][]*accesslist.AccessListMember{
+		"engineering": {
+			newTestMember(t, "engineering", "alice", accesslist.MembershipKindUser),
+			newTestMember(t, "engineering", "platform", accesslist.MembershipKindList),
+		},
+		"platform": {newTestMember(t, "platform", "bob", accesslist.MembershipKindUser)},
+		"leads":    {newTestMember(t, "leads", "carol", accesslist.MembershipKindUser)},
+	}
+
+	index := accessListRoleIndex(lists, members)
+
+	require.Equal(t, []string{"engineering"}, index["alice"]["dev"])
+	require.Equal(t, []string{"engineering"}, index["bob"]["dev"])
+	require.Equal(t, []string{"platform"}, index["bob"]["ops"])
+	require.Equal(t, []string{"engineering"}, index["carol"]["reviewer"])
+	require.Empty(t, index["carol"]["dev"])
+}
+
+func TestAccessListRoleIndex_SkipsExpiredMembers(t *testing.T) {
+	lists := []*accesslist.AccessList{newTestAccessList(t, "engineering", nil, []string{"dev"}, nil)}
+
+	expired := newTestMember(t, "engineering", "alice", accesslist.MembershipKindUser)
+	expired.Spec.Expires = time.Now().Add(-time.Hour)
+	current := newTestMember(t, "engineering", "bob", accesslist.MembershipKindUser)
+	current.Spec.Expires = time.Now().Add(time.Hour)
+	members := map[string][]*accesslist.AccessListMember{"engineering": {expired, current}}
+
+	index := accessListRoleIndex(lists, members)
+
+	require.NotContains(t, index, "alice")
+	require.Equal(t, []string{"engineering"}, index["bob"]["dev"])
+}
+
+func TestRoleSources_GrantSources(t *testing.T) {
+	local, err := types.NewUser("alice")
+	require.NoError(t, err)
+	local.SetRoles([]string{"access"})
+
+	state, err := userloginstate.New(header.Metadata{Name: "alice"}, userloginstate.Spec{
+		Roles:           []string{"access", "dev", "ops"},
+		AccessListRoles: []string{"dev", "ops"},
+	})
+	require.NoError(t, err)
+
+	sources := &roleSources{
+		loginStates:     map[string]*userloginstate.UserLoginState{"alice": state},
+		accessListRoles: map[string]map[string][]string{"alice": {"dev": {"engineering"}}},
+	}
+
+	require.ElementsMatch(t, []string{"access", "dev", "ops"}, sources.effectiveRoles(local))
+	require.Equal(t, []string{roleSourceStatic}, sources.grantSources(local, "access"))
+	require.Equal(t, []string{"access_list:engineering"}, sources.grantSources(local, "dev"))
+	require.Equal(t, []string{roleSourceAccessList}, sources.grantSources(local, "ops"))
+
+	sso, err := types.NewUser("bob")
+	require.NoError(t, err)
+	sso.SetRoles([]string{"access"})
+	sso.SetCreatedBy(types.CreatedBy{Connector: &types.ConnectorRef{Type: "saml", ID: "okta"}})
+
+	require.Equal(t, []string{"access"}, sources.effectiveRoles(sso))
+	require.Equal(t, []string{roleSourceSSOConnector}, sources.grantSources(sso, "access"))
+}
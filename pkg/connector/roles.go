@@ -3,25 +3,28 @@ package connector
 import (
 	"context"
 	"fmt"
+	"slices"
 
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
 	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/trace"
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
 	"go.uber.org/zap"
 
 	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
 	"github.com/conductorone/baton-sdk/pkg/types/grant"
 	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
-	"github.com/conductorone/baton-teleport/pkg/client"
 )
 
 const roleMembership = "member"
 
 type roleBuilder struct {
 	resourceType *v2.ResourceType
-	client       *client.TeleportClient
-	userCache    []types.User
+	clusters     *clusterSet
+	// ruleEntitlements models each resource rule verb as an entitlement.
+	ruleEntitlements bool
 }
 
 func (r *roleBuilder) ResourceType(_ context.Context) *v2.ResourceType {
@@ -29,59 +32,63 @@ func (r *roleBuilder) ResourceType(_ context.Context) *v2.ResourceType {
 }
 
 // Create a new connector resource for a Teleport role.
+// The profile carries the role's full policy: its allow and deny conditions,
+// resource rules and options.
 func getRoleResource(role types.Role) (*v2.Resource, error) {
 	roleName := role.GetMetadata().Name
+	profile := rolePolicyProfile(role)
+	profile["role_id"] = role.GetMetadata().Revision
+	profile["role_name"] = roleName
+	profile["role_description"] = role.GetMetadata().Description
+
 	return rs.NewRoleResource(
 		role.GetName(),
 		roleResourceType,
 		roleName,
 		[]rs.RoleTraitOption{
-			rs.WithRoleProfile(
-				map[string]interface{}{
-					"role_id":          role.GetMetadata().Revision,
-					"role_name":        roleName,
-					"role_description": role.GetMetadata().Description,
-				},
-			),
+			rs.WithRoleProfile(profile),
 		},
 	)
 }
 
-func (r *roleBuilder) GetUsers(ctx context.Context) ([]types.User, error) {
-	if len(r.userCache) != 0 {
-		return r.userCache, nil
-	}
-
-	users, err := r.client.GetUsers(ctx, false)
-	if err != nil {
-		return []types.User{}, err
+// findRole returns the named role from the cluster's index for the sync,
+// reading it directly when it was created since the index was built.
+func (r *roleBuilder) findRole(ctx context.Context, scope *clusterScope, syncID, name string) (types.Role, error) {
+	role, err := r.clusters.index(scope, syncID).Role(ctx, name)
+	if err != nil || role != nil {
+		return role, err
 	}
-
-	r.userCache = users
-	return users, nil
+	return scope.client.GetRole(ctx, name)
 }
 
-// List returns all the roles from the database as resource objects.
-// Roles include a RoleTrait because they are the 'shape' of a standard role.
-func (r *roleBuilder) List(ctx context.Context, _ *v2.ResourceId, _ rs.SyncOpAttrs) ([]*v2.Resource, *rs.SyncOpResults, error) {
+// List returns the roles from the database one page at a time as resource
+// objects. Roles include a RoleTrait because they are the 'shape' of a
+// standard role.
+func (r *roleBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, opts rs.SyncOpAttrs) ([]*v2.Resource, *rs.SyncOpResults, error) {
 	var rv []*v2.Resource
-	roles, err := r.client.GetRoles(ctx)
+	if r.clusters.skipList(roleResourceType.Id, parentResourceID) {
+		return nil, nil, nil
+	}
+
+	scope, err := r.clusters.scope(ctx, roleResourceType.Id, parentResourceID)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	roles, nextToken, err := scope.client.GetRolesPage(ctx, &pagination.Token{Token: opts.PageToken.Token, Size: opts.PageToken.Size})
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to list roles: %w", err)
+	}
+
 	for _, role := range roles {
-		roleCopy := role
-		rr, err := getRoleResource(roleCopy)
+		rr, err := getRoleResource(role)
 		if err != nil {
 			return nil, nil, err
 		}
-		rv = append(rv, rr)
+		rv = append(rv, scope.scopeResource(rr))
 	}
 
-	// clear the cache
-	r.userCache = []types.User{}
-	return rv, nil, nil
+	return rv, &rs.SyncOpResults{NextPageToken: nextToken}, nil
 }
 
 func (r *roleBuilder) Get(ctx context.Context, resourceId *v2.ResourceId, _ *v2.ResourceId) (*v2.Resource, annotations.Annotations, error) {
@@ -89,7 +96,12 @@ func (r *roleBuilder) Get(ctx context.Context, resourceId *v2.ResourceId, _ *v2.
 		return nil, nil, fmt.Errorf("baton-teleport: resourceId is required")
 	}
 
-	role, err := r.client.GetRole(ctx, resourceId.Resource)
+	scope, name, err := r.clusters.scopeOf(ctx, roleResourceType.Id, resourceId.Resource)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	role, err := scope.client.GetRole(ctx, name)
 	if err != nil {
 		return nil, nil, fmt.Errorf("baton-teleport: failed to get role %s: %w", resourceId.Resource, err)
 	}
@@ -99,142 +111,289 @@ func (r *roleBuilder) Get(ctx context.Context, resourceId *v2.ResourceId, _ *v2.
 		return nil, nil, err
 	}
 
-	return res, nil, nil
+	return scope.scopeResource(res), nil, nil
 }
 
-func (r *roleBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ rs.SyncOpAttrs) ([]*v2.Entitlement, *rs.SyncOpResults, error) {
-	return []*v2.Entitlement{
+// Entitlements returns the role membership entitlement, plus the can_request
+// and can_review entitlements held by roles that can request or review
+// access to this one. With rule entitlements enabled, every resource rule
+// verb the role allows is its own entitlement.
+//
+// Membership is held by users and bots, and also by root cluster roles
+// mapped through a trusted cluster and by IdP groups an SSO connector maps
+// to the role; only users and bots can be granted it.
+func (r *roleBuilder) Entitlements(ctx context.Context, resource *v2.Resource, opts rs.SyncOpAttrs) ([]*v2.Entitlement, *rs.SyncOpResults, error) {
+	rv := []*v2.Entitlement{
 		ent.NewAssignmentEntitlement(
 			resource,
 			roleMembership,
-			ent.WithGrantableTo(userResourceType),
+			ent.WithGrantableTo(userResourceType, botResourceType, roleResourceType, ssoGroupResourceType),
 			ent.WithDisplayName(fmt.Sprintf("%s Role %s", resource.DisplayName, roleMembership)),
 			ent.WithDescription(fmt.Sprintf("Member of %s Teleport role", resource.DisplayName)),
 		),
-	}, nil, nil
+	}
+
+	rv = append(rv, roleRelationEntitlements(resource)...)
+
+	if r.ruleEntitlements {
+		scope, err := r.clusters.resourceScope(ctx, resource)
+		if err != nil {
+			return nil, nil, err
+		}
+		role, err := r.findRole(ctx, scope, opts.SyncID, scope.teleportName(resource.Id.Resource))
+		if err != nil {
+			return nil, nil, fmt.Errorf("baton-teleport: failed to get role %s: %w", resource.Id.Resource, err)
+		}
+		rv = append(rv, roleRuleEntitlements(resource, roleRuleVerbs(role))...)
+	}
+
+	return rv, nil, nil
 }
 
-func (r *roleBuilder) Grants(ctx context.Context, resource *v2.Resource, _ rs.SyncOpAttrs) ([]*v2.Grant, *rs.SyncOpResults, error) {
+// Grants returns a grant for every user holding the role, whether it is set
+// on the user, mapped by an SSO connector or applied by an Access List, and
+// for every bot that can assume it. User grants carry their sources as
+// metadata so reviewers can tell whether revoking the role in Teleport will
+// stick. Members are read from the cluster's index, built once per sync,
+// and returned a page at a time; the grants derived from role definitions
+// come with the first page.
+func (r *roleBuilder) Grants(ctx context.Context, resource *v2.Resource, opts rs.SyncOpAttrs) ([]*v2.Grant, *rs.SyncOpResults, error) {
 	var rv []*v2.Grant
-	users, err := r.GetUsers(ctx)
+	scope, err := r.clusters.resourceScope(ctx, resource)
 	if err != nil {
 		return nil, nil, err
 	}
+	roleName := scope.teleportName(resource.Id.Resource)
 
-	for _, user := range users {
-		userCopy := user
-		ur, err := userResource(resource.Id, userCopy)
-		if err != nil {
-			return nil, nil, fmt.Errorf("error creating user resource for role %s: %w", resource.Id.Resource, err)
+	index := r.clusters.index(scope, opts.SyncID)
+	members, err := index.Members(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	page, nextToken, err := members.page(roleName, opts.PageToken.Token, opts.PageToken.Size)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, member := range page {
+		var grantOpts []grant.GrantOption
+		if member.principal.ResourceType == userResourceType.Id {
+			grantOpts = append(grantOpts, grant.WithGrantMetadata(map[string]interface{}{
+				roleSourcesKey: stringsToInterfaces(member.sources),
+			}))
 		}
+		rv = append(rv, grant.NewGrant(resource, roleMembership, member.principal, grantOpts...))
+	}
 
-		for _, role := range user.GetRoles() {
-			if role != resource.DisplayName {
-				continue
-			}
+	if opts.PageToken.Token != "" {
+		return rv, &rs.SyncOpResults{NextPageToken: nextToken}, nil
+	}
+
+	roles, err := index.Roles(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
 
-			gr := grant.NewGrant(resource, roleMembership, ur.Id)
-			rv = append(rv, gr)
+	rv = append(rv, roleRelationGrants(resource, scope, roles)...)
+
+	if r.ruleEntitlements {
+		role, err := r.findRole(ctx, scope, opts.SyncID, roleName)
+		if err != nil {
+			return nil, nil, fmt.Errorf("baton-teleport: failed to get role %s: %w", resource.Id.Resource, err)
 		}
+		rv = append(rv, roleRuleGrants(resource, roleRuleVerbs(role))...)
 	}
 
-	return rv, nil, nil
+	return rv, &rs.SyncOpResults{NextPageToken: nextToken}, nil
 }
 
+// Grant adds an existing Teleport role to a user or bot. Only the role list
+// is changed, through MutateUser; logins and other traits are left as they
+// are. Granting a role the user already holds is reported with a
+// GrantAlreadyExists annotation. The principal must belong to the cluster of
+// the role.
 func (r *roleBuilder) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) ([]*v2.Grant, annotations.Annotations, error) {
 	l := ctxzap.Extract(ctx)
-	userName := principal.Id.Resource
-	roleName := entitlement.Resource.Id.Resource
-	if principal.Id.ResourceType != userResourceType.Id {
-		l.Warn(
-			"baton-teleport: only users can be granted role membership",
-			zap.String("principal_type", principal.Id.ResourceType),
-			zap.String("principal_id", principal.Id.Resource),
-		)
-		return nil, nil, fmt.Errorf("baton-teleport: only users can be granted role membership")
+
+	if slug := entitlementSlug(entitlement); slug != roleMembership {
+		return nil, nil, fmt.Errorf("baton-teleport: %s is derived from role definitions and cannot be granted", slug)
 	}
 
-	prodRole, err := types.NewRole(roleName, types.RoleSpecV6{
-		Options: types.RoleOptions{
-			RequireMFAType: types.RequireMFAType_SESSION,
-		},
-		Allow: types.RoleConditions{
-			Logins:     []string{userName},
-			NodeLabels: types.Labels{},
-		},
-	})
+	scope, roleName, err := r.clusters.scopeOf(ctx, roleResourceType.Id, entitlement.Resource.Id.Resource)
 	if err != nil {
 		return nil, nil, err
 	}
-
-	user, err := r.client.GetUser(ctx, userName, false)
+	userName, err := principalName(scope, principal.Id)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	user.SetLogins(append(user.GetLogins(), userName))
-	user.AddRole(prodRole.GetName())
-	updatedUser, err := r.client.UpdateUser(ctx, user.(*types.UserV2))
+	if _, err := scope.client.GetRole(ctx, roleName); err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to get role %s: %w", roleName, err)
+	}
+
+	if principal.Id.ResourceType == botResourceType.Id {
+		changed, err := updateBotRoles(ctx, scope.client, userName, func(roles []string) []string {
+			if slices.Contains(roles, roleName) {
+				return roles
+			}
+			return append(roles, roleName)
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("baton-teleport: failed to grant role to bot: %w", err)
+		}
+		if !changed {
+			return nil, annotations.New(&v2.GrantAlreadyExists{}), nil
+		}
+
+		l.Info("Bot role has been granted.", zap.String("bot", principal.Id.Resource), zap.String("role", roleName))
+		return nil, nil, nil
+	}
+
+	if principal.Id.ResourceType != userResourceType.Id {
+		l.Warn(
+			"baton-teleport: only users and bots can be granted role membership",
+			zap.String("principal_type", principal.Id.ResourceType),
+			zap.String("principal_id", principal.Id.Resource),
+		)
+		return nil, nil, fmt.Errorf("baton-teleport: only users and bots can be granted role membership")
+	}
+
+	updatedUser, changed, err := scope.client.MutateUser(ctx, userName, func(user types.User) (bool, error) {
+		if slices.Contains(user.GetRoles(), roleName) {
+			return false, nil
+		}
+		user.AddRole(roleName)
+		return true, nil
+	})
 	if err != nil {
 		return nil, nil, fmt.Errorf("baton-teleport: failed to grant role: %w", err)
 	}
+	if !changed {
+		l.Info("Role membership already exists.", zap.String("user", userName), zap.String("role", roleName))
+		return nil, annotations.New(&v2.GrantAlreadyExists{}), nil
+	}
 
-	l.Warn("Role Membership has been created.",
+	l.Info("Role Membership has been created.",
 		zap.String("Name", updatedUser.GetName()),
-		zap.String("Namespace", updatedUser.GetMetadata().Namespace),
-		zap.Time("CreatedAt", updatedUser.GetCreatedBy().Time),
+		zap.String("Role", roleName),
 	)
 
 	return nil, nil, nil
 }
 
+// Revoke removes a role from a user or bot. Logins and other traits are left
+// as they are. Revoking a role the principal does not hold is reported with a
+// GrantAlreadyRevoked annotation, and a user's last role is never removed
+// because Teleport requires every user to hold at least one. The user is
+// updated through MutateUser, so a concurrent edit is retried against the
+// fresh copy rather than overwritten.
 func (r *roleBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
 	l := ctxzap.Extract(ctx)
-	var roleList []string
 	entitlement := grant.Entitlement
 	principal := grant.Principal
 
+	if slug := entitlementSlug(entitlement); slug != roleMembership {
+		return nil, fmt.Errorf("baton-teleport: %s is derived from role definitions and cannot be revoked", slug)
+	}
+
+	scope, roleName, err := r.clusters.scopeOf(ctx, roleResourceType.Id, entitlement.Resource.Id.Resource)
+	if err != nil {
+		return nil, err
+	}
+	userName, err := principalName(scope, principal.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if principal.Id.ResourceType == botResourceType.Id {
+		changed, err := updateBotRoles(ctx, scope.client, userName, func(roles []string) []string {
+			return slices.DeleteFunc(roles, func(role string) bool { return role == roleName })
+		})
+		if err != nil {
+			return nil, fmt.Errorf("baton-teleport: failed to revoke role from bot: %w", err)
+		}
+		if !changed {
+			return annotations.New(&v2.GrantAlreadyRevoked{}), nil
+		}
+
+		l.Info("Bot role has been revoked.", zap.String("bot", principal.Id.Resource), zap.String("role", roleName))
+		return nil, nil
+	}
+
 	if principal.Id.ResourceType != userResourceType.Id {
 		l.Warn(
-			"baton-teleport: only users can have role membership revoked",
+			"baton-teleport: only users and bots can have role membership revoked",
 			zap.String("principal_type", principal.Id.ResourceType),
 			zap.String("principal_id", principal.Id.Resource),
 		)
-		return nil, fmt.Errorf("baton-teleport: only users can have role membership revoked")
+		return nil, fmt.Errorf("baton-teleport: only users and bots can have role membership revoked")
 	}
 
-	roleName := entitlement.Resource.Id.Resource
-	userName := principal.Id.Resource
-	user, err := r.client.GetUser(ctx, userName, false)
+	updatedUser, changed, err := scope.client.MutateUser(ctx, userName, func(user types.User) (bool, error) {
+		roles, err := revokeRole(user.GetRoles(), roleName)
+		if err != nil || roles == nil {
+			return false, err
+		}
+		user.SetRoles(roles)
+		return true, nil
+	})
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("baton-teleport: failed to revoke role %s from user %s: %w", roleName, userName, err)
 	}
-
-	user.SetLogins(append(user.GetLogins(), userName))
-	for _, role := range user.GetRoles() {
-		if role != roleName {
-			roleList = append(roleList, role)
+	if !changed {
+		// A role applied by an Access List is not stored on the user and
+		// would be re-applied on the next login, so it cannot be revoked here.
+		state, err := scope.client.UserLoginStateClient().GetUserLoginState(ctx, userName)
+		if err != nil && !trace.IsNotFound(err) && !isUnavailable(err) {
+			return nil, fmt.Errorf("baton-teleport: failed to get login state of user %s: %w", userName, err)
+		}
+		if err == nil && slices.Contains(state.GetAccessListRoles(), roleName) {
+			return nil, fmt.Errorf("baton-teleport: role %s is granted to user %s by an access list, remove the user from the access list instead", roleName, userName)
 		}
-	}
 
-	user.SetRoles(roleList)
-	updatedUser, err := r.client.UpdateUser(ctx, user.(*types.UserV2))
-	if err != nil {
-		return nil, fmt.Errorf("baton-teleport: failed to revoke role: %w", err)
+		l.Info("Role membership already revoked.", zap.String("user", userName), zap.String("role", roleName))
+		return annotations.New(&v2.GrantAlreadyRevoked{}), nil
 	}
 
-	l.Warn("Role Membership has been revoked.",
+	l.Info("Role Membership has been revoked.",
 		zap.String("Name", updatedUser.GetName()),
-		zap.String("Namespace", updatedUser.GetMetadata().Namespace),
-		zap.Time("CreatedAt", updatedUser.GetCreatedBy().Time),
+		zap.String("Role", roleName),
 	)
 
 	return nil, nil
 }
 
-func newRoleBuilder(c *client.TeleportClient) *roleBuilder {
+// revokeRole returns roles without roleName. It returns nil if roleName is
+// not held, and an error if it is the only role held.
+func revokeRole(roles []string, roleName string) ([]string, error) {
+	if !slices.Contains(roles, roleName) {
+		return nil, nil
+	}
+
+	remaining := slices.DeleteFunc(slices.Clone(roles), func(role string) bool { return role == roleName })
+	if len(remaining) == 0 {
+		return nil, fmt.Errorf("cannot remove the last role of a user, Teleport requires at least one")
+	}
+
+	return remaining, nil
+}
+
+// principalName returns the Teleport name of a principal of a role in the
+// cluster, and an error if the principal belongs to another cluster.
+func principalName(scope *clusterScope, principal *v2.ResourceId) (string, error) {
+	name := scope.teleportName(principal.Resource)
+	if scope.resourceID(name) != principal.Resource {
+		return "", fmt.Errorf("baton-teleport: %s %s does not belong to cluster %s", principal.ResourceType, principal.Resource, scope.name)
+	}
+	return name, nil
+}
+
+func newRoleBuilder(clusters *clusterSet, ruleEntitlements bool) *roleBuilder {
 	return &roleBuilder{
-		resourceType: roleResourceType,
-		client:       c,
+		resourceType:     roleResourceType,
+		clusters:         clusters,
+		ruleEntitlements: ruleEntitlements,
 	}
 }
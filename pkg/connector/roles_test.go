@@ -32,7 +32,7 @@ func TestRoles(t *testing.T) {
 
 	r := &roleBuilder{
 		resourceType: roleResourceType,
-		client:       cliTest,
+		clusters:     newClusterSet(cliTest, false),
 	}
 
 	principal := GetUserResourceForTesting(t, userName, userDescription)
@@ -59,4 +59,25 @@ func TestRoles(t *testing.T) {
 		_, _, err = r.Grant(ctx, principal, entitlement)
 		require.Nil(t, err)
 	})
+
+	t.Run("role builder grant an already granted role", func(t *testing.T) {
+		entitlement := GetEntitlementForTesting(resource, roleName, roleEntitlement)
+
+		_, annos, err := r.Grant(ctx, principal, entitlement)
+		require.Nil(t, err)
+		require.True(t, annos.Contains(&v2.GrantAlreadyExists{}))
+	})
+}
+
+func TestRevokeRole(t *testing.T) {
+	roles, err := revokeRole([]string{"access", "editor"}, "editor")
+	require.NoError(t, err)
+	require.Equal(t, []string{"access"}, roles)
+
+	roles, err = revokeRole([]string{"access"}, "editor")
+	require.NoError(t, err)
+	require.Nil(t, roles)
+
+	_, err = revokeRole([]string{"editor"}, "editor")
+	require.ErrorContains(t, err, "last role")
 }
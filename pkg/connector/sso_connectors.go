@@ -0,0 +1,287 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/gravitational/teleport/api/constants"
+	"github.com/gravitational/teleport/api/types"
+
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/conductorone/baton-teleport/pkg/client"
+)
+
+const ssoMappingsKey = "mappings"
+
+// ssoMapping is one rule of an SSO connector mapping an IdP group, claim or
+// team to Teleport roles.
+type ssoMapping struct {
+	// slug identifies the IdP side of the mapping, such as groups=eng-oncall
+	// for SAML and OIDC or acme/eng-oncall for GitHub.
+	slug        string
+	description string
+	roles       []string
+
+	// trait and value match the IdP side against the traits Teleport copies
+	// from the IdP assertion or claims onto the user at login. GitHub
+	// mappings have no trait: the team trait of GitHub users does not name
+	// the organization, so the members of a team cannot be told apart.
+	trait string
+	value string
+}
+
+// ssoConnector is the part of a SAML, OIDC or GitHub connector the sync
+// needs, independent of its kind.
+type ssoConnector struct {
+	name     string
+	display  string
+	profile  map[string]interface{}
+	mappings []ssoMapping
+}
+
+// ssoConnectorKind lists and reads the connectors of one SSO protocol.
+// userType is the connector type recorded on the users the connector
+// creates.
+type ssoConnectorKind struct {
+	resourceType *v2.ResourceType
+	userType     string
+	list         func(ctx context.Context, c *client.TeleportClient, token *pagination.Token) ([]ssoConnector, string, error)
+	get          func(ctx context.Context, c *client.TeleportClient, name string) (ssoConnector, error)
+}
+
+var samlConnectorKind = ssoConnectorKind{
+	resourceType: samlConnectorResourceType,
+	userType:     constants.SAML,
+	list: func(ctx context.Context, c *client.TeleportClient, token *pagination.Token) ([]ssoConnector, string, error) {
+		connectors, next, err := c.GetSAMLConnectorsPage(ctx, token)
+		return convertSSOConnectors(connectors, newSAMLConnector), next, err
+	},
+	get: func(ctx context.Context, c *client.TeleportClient, name string) (ssoConnector, error) {
+		connector, err := c.GetSAMLConnector(ctx, name, false)
+		if err != nil {
+			return ssoConnector{}, err
+		}
+		return newSAMLConnector(connector), nil
+	},
+}
+
+var oidcConnectorKind = ssoConnectorKind{
+	resourceType: oidcConnectorResourceType,
+	userType:     constants.OIDC,
+	list: func(ctx context.Context, c *client.TeleportClient, token *pagination.Token) ([]ssoConnector, string, error) {
+		connectors, next, err := c.GetOIDCConnectorsPage(ctx, token)
+		return convertSSOConnectors(connectors, newOIDCConnector), next, err
+	},
+	get: func(ctx context.Context, c *client.TeleportClient, name string) (ssoConnector, error) {
+		connector, err := c.GetOIDCConnector(ctx, name, false)
+		if err != nil {
+			return ssoConnector{}, err
+		}
+		return newOIDCConnector(connector), nil
+	},
+}
+
+var githubConnectorKind = ssoConnectorKind{
+	resourceType: githubConnectorResourceType,
+	userType:     constants.Github,
+	list: func(ctx context.Context, c *client.TeleportClient, token *pagination.Token) ([]ssoConnector, string, error) {
+		connectors, next, err := c.GetGithubConnectorsPage(ctx, token)
+		return convertSSOConnectors(connectors, newGithubConnector), next, err
+	},
+	get: func(ctx context.Context, c *client.TeleportClient, name string) (ssoConnector, error) {
+		connector, err := c.GetGithubConnector(ctx, name, false)
+		if err != nil {
+			return ssoConnector{}, err
+		}
+		return newGithubConnector(connector), nil
+	},
+}
+
+// ssoConnectorKinds maps the resource type of each kind of SSO connector to
+// its kind.
+var ssoConnectorKinds = map[string]ssoConnectorKind{
+	samlConnectorResourceType.Id:   samlConnectorKind,
+	oidcConnectorResourceType.Id:   oidcConnectorKind,
+	githubConnectorResourceType.Id: githubConnectorKind,
+}
+
+func convertSSOConnectors[T any](connectors []T, convert func(T) ssoConnector) []ssoConnector {
+	rv := make([]ssoConnector, 0, len(connectors))
+	for _, connector := range connectors {
+		rv = append(rv, convert(connector))
+	}
+	return rv
+}
+
+func newSAMLConnector(connector types.SAMLConnector) ssoConnector {
+	var mappings []ssoMapping
+	for _, m := range connector.GetAttributesToRoles() {
+		mappings = append(mappings, ssoMapping{
+			slug:        m.Name + "=" + m.Value,
+			description: fmt.Sprintf("Users whose SAML attribute %s matches %s", m.Name, m.Value),
+			roles:       m.Roles,
+			trait:       m.Name,
+			value:       m.Value,
+		})
+	}
+
+	return ssoConnector{
+		name:    connector.GetName(),
+		display: connector.GetDisplay(),
+		profile: map[string]interface{}{
+			"provider":              connector.GetProvider(),
+			"issuer":                connector.GetIssuer(),
+			"sso_url":               connector.GetSSO(),
+			"audience":              connector.GetAudience(),
+			"entity_descriptor_url": connector.GetEntityDescriptorURL(),
+		},
+		mappings: mappings,
+	}
+}
+
+func newOIDCConnector(connector types.OIDCConnector) ssoConnector {
+	var mappings []ssoMapping
+	for _, m := range connector.GetClaimsToRoles() {
+		mappings = append(mappings, ssoMapping{
+			slug:        m.Claim + "=" + m.Value,
+			description: fmt.Sprintf("Users whose OIDC claim %s matches %s", m.Claim, m.Value),
+			roles:       m.Roles,
+			trait:       m.Claim,
+			value:       m.Value,
+		})
+	}
+
+	return ssoConnector{
+		name:    connector.GetName(),
+		display: connector.GetDisplay(),
+		profile: map[string]interface{}{
+			"provider":   connector.GetProvider(),
+			"issuer_url": connector.GetIssuerURL(),
+			"client_id":  connector.GetClientID(),
+		},
+		mappings: mappings,
+	}
+}
+
+func newGithubConnector(connector types.GithubConnector) ssoConnector {
+	var mappings []ssoMapping
+	for _, m := range connector.GetTeamsToRoles() {
+		mappings = append(mappings, ssoMapping{
+			slug:        m.Organization + "/" + m.Team,
+			description: fmt.Sprintf("Members of the GitHub team %s in the %s organization", m.Team, m.Organization),
+			roles:       m.Roles,
+		})
+	}
+
+	return ssoConnector{
+		name:    connector.GetName(),
+		display: connector.GetDisplay(),
+		profile: map[string]interface{}{
+			"client_id":    connector.GetClientID(),
+			"endpoint_url": connector.GetEndpointURL(),
+		},
+		mappings: mappings,
+	}
+}
+
+type ssoConnectorBuilder struct {
+	resourceType *v2.ResourceType
+	client       *client.TeleportClient
+	kind         ssoConnectorKind
+}
+
+func (s *ssoConnectorBuilder) ResourceType(_ context.Context) *v2.ResourceType {
+	return s.resourceType
+}
+
+// Create a new connector resource for a Teleport SSO connector. The profile
+// lists the IdP side of every role mapping next to the roles it grants, and
+// the IdP groups are synced as child resources.
+func getSSOConnectorResource(resourceType *v2.ResourceType, connector ssoConnector) (*v2.Resource, error) {
+	mappings := make(map[string]interface{}, len(connector.mappings))
+	for _, m := range connector.mappings {
+		roles, _ := mappings[m.slug].([]interface{})
+		mappings[m.slug] = append(roles, stringsToInterfaces(m.roles)...)
+	}
+
+	profile := map[string]interface{}{
+		"connector_name": connector.name,
+		"display":        connector.display,
+		ssoMappingsKey:   mappings,
+	}
+	for key, value := range connector.profile {
+		profile[key] = value
+	}
+
+	displayName := connector.name
+	if connector.display != "" {
+		displayName = connector.display
+	}
+
+	return rs.NewRoleResource(
+		displayName,
+		resourceType,
+		connector.name,
+		[]rs.RoleTraitOption{
+			rs.WithRoleProfile(profile),
+		},
+		rs.WithAnnotation(&v2.ChildResourceType{ResourceTypeId: ssoGroupResourceType.Id}),
+	)
+}
+
+// List returns the SSO connectors of one kind from Teleport as resource
+// objects, one page at a time. Secrets are never read.
+func (s *ssoConnectorBuilder) List(ctx context.Context, _ *v2.ResourceId, opts rs.SyncOpAttrs) ([]*v2.Resource, *rs.SyncOpResults, error) {
+	var rv []*v2.Resource
+	connectors, nextToken, err := s.kind.list(ctx, s.client, &pagination.Token{Token: opts.PageToken.Token, Size: opts.PageToken.Size})
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to list %s resources: %w", s.resourceType.Id, err)
+	}
+
+	for _, connector := range connectors {
+		cr, err := getSSOConnectorResource(s.resourceType, connector)
+		if err != nil {
+			return nil, nil, fmt.Errorf("baton-teleport: failed to create %s resource: %w", s.resourceType.Id, err)
+		}
+		rv = append(rv, cr)
+	}
+
+	return rv, &rs.SyncOpResults{NextPageToken: nextToken}, nil
+}
+
+func (s *ssoConnectorBuilder) Get(ctx context.Context, resourceId *v2.ResourceId, _ *v2.ResourceId) (*v2.Resource, annotations.Annotations, error) {
+	if resourceId == nil {
+		return nil, nil, fmt.Errorf("baton-teleport: resourceId is required")
+	}
+
+	connector, err := s.kind.get(ctx, s.client, resourceId.Resource)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to get %s %s: %w", s.resourceType.Id, resourceId.Resource, err)
+	}
+
+	cr, err := getSSOConnectorResource(s.resourceType, connector)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to create %s resource: %w", s.resourceType.Id, err)
+	}
+
+	return cr, nil, nil
+}
+
+func (s *ssoConnectorBuilder) Entitlements(_ context.Context, _ *v2.Resource, _ rs.SyncOpAttrs) ([]*v2.Entitlement, *rs.SyncOpResults, error) {
+	return nil, nil, nil
+}
+
+func (s *ssoConnectorBuilder) Grants(_ context.Context, _ *v2.Resource, _ rs.SyncOpAttrs) ([]*v2.Grant, *rs.SyncOpResults, error) {
+	return nil, nil, nil
+}
+
+func newSSOConnectorBuilder(c *client.TeleportClient, kind ssoConnectorKind) *ssoConnectorBuilder {
+	return &ssoConnectorBuilder{
+		resourceType: kind.resourceType,
+		client:       c,
+		kind:         kind,
+	}
+}
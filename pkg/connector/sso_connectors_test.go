@@ -0,0 +1,39 @@
+package connector
+
+import (
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/stretchr/testify/require"
+
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+func TestGetSSOConnectorResource(t *testing.T) {
+	connector := ssoConnector{
+		name:    "okta",
+		display: "Okta",
+		mappings: []ssoMapping{
+			{slug: "groups=eng-oncall", roles: []string{"prod-access", "access"}},
+			{slug: "groups=admins", roles: []string{"prod-access", "$1"}},
+		},
+	}
+
+	res, err := getSSOConnectorResource(samlConnectorResourceType, connector)
+	require.NoError(t, err)
+	require.Equal(t, "Okta", res.DisplayName)
+	require.Equal(t, "okta", res.Id.Resource)
+
+	trait, err := rs.GetRoleTrait(res)
+	require.NoError(t, err)
+	mappings := trait.GetProfile().AsMap()[ssoMappingsKey].(map[string]interface{})
+	require.Equal(t, []interface{}{"prod-access", "access"}, mappings["groups=eng-oncall"])
+
+	child := &v2.ChildResourceType{}
+	annos := annotations.Annotations(res.Annotations)
+	ok, err := annos.Pick(child)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, ssoGroupResourceType.Id, child.ResourceTypeId)
+}
@@ -0,0 +1,339 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/gravitational/teleport/api/types"
+
+	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/conductorone/baton-teleport/pkg/client"
+)
+
+const (
+	ssoGroupMembership = "member"
+	ssoGroupRolesKey   = "roles"
+	ssoGroupTraitKey   = "trait"
+	ssoGroupValueKey   = "value"
+)
+
+// ssoGroupBuilder syncs the IdP groups, claims and teams that SSO connectors
+// map to roles. Each is nested under its connector and granted the roles it
+// maps to, so members of the IdP group inherit them.
+type ssoGroupBuilder struct {
+	resourceType *v2.ResourceType
+	client       *client.TeleportClient
+
+	// mu guards users, the users created by each SSO connector keyed by
+	// ssoUserKey, which is loaded once per sync and shared by every group.
+	mu     sync.Mutex
+	syncID string
+	users  map[string][]ssoUser
+}
+
+// ssoUser is a user created by an SSO connector, with the traits the IdP
+// reported at its last login.
+type ssoUser struct {
+	name   string
+	traits map[string][]string
+}
+
+// ssoUserKey identifies an SSO connector the way users record it.
+func ssoUserKey(connectorType, connectorName string) string {
+	return connectorType + "/" + connectorName
+}
+
+func (s *ssoGroupBuilder) ResourceType(_ context.Context) *v2.ResourceType {
+	return s.resourceType
+}
+
+// ssoGroupID names the IdP side of a mapping within its connector, such as
+// saml_connector/okta/groups=eng-oncall.
+func ssoGroupID(connector *v2.ResourceId, slug string) string {
+	return connector.ResourceType + "/" + connector.Resource + "/" + slug
+}
+
+// parseSSOGroupID splits an ID built by ssoGroupID into the connector it
+// belongs to and the mapping slug.
+func parseSSOGroupID(id string) (*v2.ResourceId, string, error) {
+	parts := strings.SplitN(id, "/", 3)
+	if len(parts) != 3 || parts[1] == "" || parts[2] == "" {
+		return nil, "", fmt.Errorf("baton-teleport: invalid SSO group id %q", id)
+	}
+	return &v2.ResourceId{ResourceType: parts[0], Resource: parts[1]}, parts[2], nil
+}
+
+// ssoGroups merges the mappings of a connector by IdP group, in the order
+// they first appear, so a group named by several rules is synced once with
+// every role it maps to.
+func ssoGroups(mappings []ssoMapping) []ssoMapping {
+	var rv []ssoMapping
+	index := map[string]int{}
+	for _, m := range mappings {
+		i, ok := index[m.slug]
+		if !ok {
+			index[m.slug] = len(rv)
+			rv = append(rv, ssoMapping{slug: m.slug, description: m.description, trait: m.trait, value: m.value})
+			i = len(rv) - 1
+		}
+		for _, role := range m.roles {
+			rv[i].roles = appendUnique(rv[i].roles, role)
+		}
+	}
+	return rv
+}
+
+// Create a new connector resource for an IdP group of an SSO connector. The
+// profile lists the roles the group maps to, including unresolved regular
+// expression captures and trait templates, and the user trait and value
+// that decide membership when they are known.
+func getSSOGroupResource(connector *v2.ResourceId, group ssoMapping) (*v2.Resource, error) {
+	profile := map[string]interface{}{
+		"connector_name": connector.Resource,
+		"mapping":        group.slug,
+		ssoGroupRolesKey: stringsToInterfaces(group.roles),
+	}
+	if group.trait != "" {
+		profile[ssoGroupTraitKey] = group.trait
+		profile[ssoGroupValueKey] = group.value
+	}
+
+	return rs.NewGroupResource(
+		group.slug,
+		ssoGroupResourceType,
+		ssoGroupID(connector, group.slug),
+		[]rs.GroupTraitOption{
+			rs.WithGroupProfile(profile),
+		},
+		rs.WithParentResourceID(connector),
+		rs.WithDescription(group.description),
+	)
+}
+
+// List returns the IdP groups mapped to roles by the SSO connector parent.
+func (s *ssoGroupBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, _ rs.SyncOpAttrs) ([]*v2.Resource, *rs.SyncOpResults, error) {
+	if parentResourceID == nil {
+		return nil, nil, nil
+	}
+
+	groups, err := s.connectorGroups(ctx, parentResourceID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rv := make([]*v2.Resource, 0, len(groups))
+	for _, group := range groups {
+		gr, err := getSSOGroupResource(parentResourceID, group)
+		if err != nil {
+			return nil, nil, fmt.Errorf("baton-teleport: failed to create SSO group resource: %w", err)
+		}
+		rv = append(rv, gr)
+	}
+
+	return rv, nil, nil
+}
+
+func (s *ssoGroupBuilder) Get(ctx context.Context, resourceId *v2.ResourceId, _ *v2.ResourceId) (*v2.Resource, annotations.Annotations, error) {
+	if resourceId == nil {
+		return nil, nil, fmt.Errorf("baton-teleport: resourceId is required")
+	}
+
+	connector, slug, err := parseSSOGroupID(resourceId.Resource)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	groups, err := s.connectorGroups(ctx, connector)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, group := range groups {
+		if group.slug != slug {
+			continue
+		}
+		gr, err := getSSOGroupResource(connector, group)
+		if err != nil {
+			return nil, nil, fmt.Errorf("baton-teleport: failed to create SSO group resource: %w", err)
+		}
+		return gr, nil, nil
+	}
+
+	return nil, nil, fmt.Errorf("baton-teleport: %s %s does not map %s to any role", connector.ResourceType, connector.Resource, slug)
+}
+
+// connectorGroups reads the SSO connector and returns its IdP groups.
+func (s *ssoGroupBuilder) connectorGroups(ctx context.Context, connector *v2.ResourceId) ([]ssoMapping, error) {
+	kind, ok := ssoConnectorKinds[connector.ResourceType]
+	if !ok {
+		return nil, fmt.Errorf("baton-teleport: %s is not an SSO connector", connector.ResourceType)
+	}
+
+	c, err := kind.get(ctx, s.client, connector.Resource)
+	if err != nil {
+		return nil, fmt.Errorf("baton-teleport: failed to get %s %s: %w", connector.ResourceType, connector.Resource, err)
+	}
+
+	return ssoGroups(c.mappings), nil
+}
+
+// Entitlements returns the membership of the IdP group. Membership is
+// decided by the IdP, so it is never granted by Teleport; the sync reports
+// the users whose IdP traits matched the group at their last login.
+func (s *ssoGroupBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ rs.SyncOpAttrs) ([]*v2.Entitlement, *rs.SyncOpResults, error) {
+	return []*v2.Entitlement{
+		ent.NewAssignmentEntitlement(
+			resource,
+			ssoGroupMembership,
+			ent.WithGrantableTo(userResourceType),
+			ent.WithDisplayName(fmt.Sprintf("%s %s", resource.DisplayName, ssoGroupMembership)),
+			ent.WithDescription(fmt.Sprintf("Member of the IdP group %s", resource.DisplayName)),
+		),
+	}, nil, nil
+}
+
+// Grants returns the group's membership, held by the users the connector
+// created whose traits match the mapping, and a grant of each mapped role's
+// member entitlement to the group. The role grants are expanded from the
+// membership when the members are known; GitHub teams have none.
+func (s *ssoGroupBuilder) Grants(ctx context.Context, resource *v2.Resource, opts rs.SyncOpAttrs) ([]*v2.Grant, *rs.SyncOpResults, error) {
+	trait, err := rs.GetGroupTrait(resource)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to get group trait of %s: %w", resource.Id.Resource, err)
+	}
+	profile := trait.GetProfile().AsMap()
+
+	var roles []string
+	if values, ok := profile[ssoGroupRolesKey].([]interface{}); ok {
+		for _, value := range values {
+			if role, ok := value.(string); ok {
+				roles = append(roles, role)
+			}
+		}
+	}
+
+	traitName, _ := profile[ssoGroupTraitKey].(string)
+	if traitName == "" {
+		return ssoGroupGrants(resource, roles, false), nil, nil
+	}
+	value, _ := profile[ssoGroupValueKey].(string)
+
+	connector, _, err := parseSSOGroupID(resource.Id.Resource)
+	if err != nil {
+		return nil, nil, err
+	}
+	users, err := s.connectorUsers(ctx, opts.SyncID, connector)
+	if err != nil {
+		return nil, nil, err
+	}
+	members, err := ssoGroupMembers(users, traitName, value)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to match members of %s: %w", resource.Id.Resource, err)
+	}
+
+	rv := make([]*v2.Grant, 0, len(members)+len(roles))
+	for _, member := range members {
+		principal := &v2.ResourceId{ResourceType: userResourceType.Id, Resource: member}
+		rv = append(rv, grant.NewGrant(resource, ssoGroupMembership, principal))
+	}
+	rv = append(rv, ssoGroupGrants(resource, roles, true)...)
+
+	return rv, nil, nil
+}
+
+// ssoGroupGrants returns one role grant to the group per role it maps to,
+// expanded from the group's membership when expandable is set. Roles built
+// from regular expression captures or trait templates cannot be resolved to
+// a single role and are skipped.
+func ssoGroupGrants(resource *v2.Resource, roles []string, expandable bool) []*v2.Grant {
+	var rv []*v2.Grant
+	for _, role := range roles {
+		if strings.Contains(role, "$") || strings.Contains(role, "{{") {
+			continue
+		}
+
+		var opts []grant.GrantOption
+		if expandable {
+			opts = append(opts, grant.WithAnnotation(&v2.GrantExpandable{
+				EntitlementIds: []string{fmt.Sprintf("%s:%s:%s", ssoGroupResourceType.Id, resource.Id.Resource, ssoGroupMembership)},
+			}))
+		}
+		roleResource := &v2.Resource{Id: &v2.ResourceId{ResourceType: roleResourceType.Id, Resource: role}}
+		rv = append(rv, grant.NewGrant(roleResource, roleMembership, resource.Id, opts...))
+	}
+	return rv
+}
+
+// ssoGroupMembers returns the names of the users whose trait holds a value
+// matching the mapping value.
+func ssoGroupMembers(users []ssoUser, trait, value string) ([]string, error) {
+	matcher, err := ssoValueRegexp(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var rv []string
+	for _, user := range users {
+		if slices.ContainsFunc(user.traits[trait], matcher.MatchString) {
+			rv = append(rv, user.name)
+		}
+	}
+	return rv, nil
+}
+
+// ssoValueRegexp compiles the value of a SAML or OIDC mapping the way
+// Teleport matches it: a value wrapped in ^ and $ is a regular expression,
+// and any other value is literal except for *, which matches any text.
+func ssoValueRegexp(value string) (*regexp.Regexp, error) {
+	if !strings.HasPrefix(value, "^") || !strings.HasSuffix(value, "$") {
+		value = "^" + strings.ReplaceAll(regexp.QuoteMeta(value), `\*`, "(.*)") + "$"
+	}
+	return regexp.Compile(value)
+}
+
+// connectorUsers returns the users the SSO connector created. The users of
+// every connector are read in one pass on first use in a sync.
+func (s *ssoGroupBuilder) connectorUsers(ctx context.Context, syncID string, connector *v2.ResourceId) ([]ssoUser, error) {
+	kind, ok := ssoConnectorKinds[connector.ResourceType]
+	if !ok {
+		return nil, fmt.Errorf("baton-teleport: %s is not an SSO connector", connector.ResourceType)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.users == nil || s.syncID != syncID {
+		users := map[string][]ssoUser{}
+		err := s.client.ForEachUser(ctx, func(user types.User) error {
+			ref := user.GetCreatedBy().Connector
+			if ref == nil {
+				return nil
+			}
+			key := ssoUserKey(ref.Type, ref.ID)
+			users[key] = append(users[key], ssoUser{name: user.GetName(), traits: user.GetTraits()})
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("baton-teleport: failed to list users: %w", err)
+		}
+		s.users = users
+		s.syncID = syncID
+	}
+
+	return s.users[ssoUserKey(kind.userType, connector.Resource)], nil
+}
+
+func newSSOGroupBuilder(c *client.TeleportClient) *ssoGroupBuilder {
+	return &ssoGroupBuilder{
+		resourceType: ssoGroupResourceType,
+		client:       c,
+	}
+}
@@ -0,0 +1,101 @@
+package connector
+
+import (
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSOGroups_MergedByGroup(t *testing.T) {
+	groups := ssoGroups([]ssoMapping{
+		{slug: "groups=eng-oncall", roles: []string{"prod-access", "access"}, trait: "groups", value: "eng-oncall"},
+		{slug: "groups=admins", roles: []string{"prod-access", "$1"}},
+		{slug: "groups=eng-oncall", roles: []string{"access", "{{external.roles}}"}, trait: "groups", value: "eng-oncall"},
+	})
+
+	require.Len(t, groups, 2)
+	require.Equal(t, "groups=eng-oncall", groups[0].slug)
+	require.Equal(t, []string{"prod-access", "access", "{{external.roles}}"}, groups[0].roles)
+	require.Equal(t, "groups", groups[0].trait)
+	require.Equal(t, "eng-oncall", groups[0].value)
+	require.Equal(t, "groups=admins", groups[1].slug)
+}
+
+func TestSSOGroupID_RoundTrip(t *testing.T) {
+	connector := &v2.ResourceId{ResourceType: githubConnectorResourceType.Id, Resource: "github"}
+	id := ssoGroupID(connector, "acme/eng-oncall")
+	require.Equal(t, "github_connector/github/acme/eng-oncall", id)
+
+	parsed, slug, err := parseSSOGroupID(id)
+	require.NoError(t, err)
+	require.Equal(t, connector.ResourceType, parsed.ResourceType)
+	require.Equal(t, connector.Resource, parsed.Resource)
+	require.Equal(t, "acme/eng-oncall", slug)
+
+	_, _, err = parseSSOGroupID("okta")
+	require.Error(t, err)
+}
+
+func TestSSOGroupGrants(t *testing.T) {
+	connector := &v2.ResourceId{ResourceType: samlConnectorResourceType.Id, Resource: "okta"}
+	res, err := getSSOGroupResource(connector, ssoMapping{slug: "groups=admins", roles: []string{"prod-access", "$1"}})
+	require.NoError(t, err)
+	require.Equal(t, connector, res.ParentResourceId)
+
+	grants := ssoGroupGrants(res, []string{"prod-access", "$1"}, true)
+	require.Len(t, grants, 1)
+
+	g := grants[0]
+	require.Equal(t, "role:prod-access:member", g.Entitlement.Id)
+	require.Equal(t, res.Id, g.Principal.Id)
+
+	expandable := &v2.GrantExpandable{}
+	annos := annotations.Annotations(g.Annotations)
+	ok, err := annos.Pick(expandable)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, []string{"sso_group:saml_connector/okta/groups=admins:member"}, expandable.EntitlementIds)
+}
+
+func TestSSOGroupGrants_NotExpandable(t *testing.T) {
+	connector := &v2.ResourceId{ResourceType: githubConnectorResourceType.Id, Resource: "github"}
+	res, err := getSSOGroupResource(connector, ssoMapping{slug: "acme/eng-oncall", roles: []string{"access"}})
+	require.NoError(t, err)
+
+	grants := ssoGroupGrants(res, []string{"access"}, false)
+	require.Len(t, grants, 1)
+
+	annos := annotations.Annotations(grants[0].Annotations)
+	require.False(t, annos.Contains(&v2.GrantExpandable{}))
+}
+
+func TestSSOGroupMembers(t *testing.T) {
+	users := []ssoUser{
+		{name: "alice", traits: map[string][]string{"groups": {"eng-oncall", "eng"}}},
+		{name: "bob", traits: map[string][]string{"groups": {"eng"}}},
+		{name: "carol", traits: map[string][]string{"groups": {"eng-oncall-eu"}}},
+		{name: "dave", traits: map[string][]string{"email": {"eng-oncall"}}},
+	}
+
+	members, err := ssoGroupMembers(users, "groups", "eng-oncall")
+	require.NoError(t, err)
+	require.Equal(t, []string{"alice"}, members)
+
+	members, err = ssoGroupMembers(users, "groups", "eng-oncall*")
+	require.NoError(t, err)
+	require.Equal(t, []string{"alice", "carol"}, members)
+
+	members, err = ssoGroupMembers(users, "groups", "^eng$")
+	require.NoError(t, err)
+	require.Equal(t, []string{"alice", "bob"}, members)
+
+	// Outside a regular expression, dots are literal.
+	members, err = ssoGroupMembers(users, "groups", "en.")
+	require.NoError(t, err)
+	require.Empty(t, members)
+
+	_, err = ssoGroupMembers(users, "groups", "^eng($")
+	require.Error(t, err)
+}
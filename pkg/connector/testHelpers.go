@@ -47,6 +47,7 @@ func GetUserResourceForTesting(
 				Description: description,
 			},
 		},
+		false,
 	)
 	require.Nil(t, err)
 	return principal
@@ -0,0 +1,167 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/gravitational/teleport/api/types"
+
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+const trustedClusterKey = "trusted_cluster"
+
+type trustedClusterBuilder struct {
+	resourceType *v2.ResourceType
+	clusters     *clusterSet
+}
+
+func (t *trustedClusterBuilder) ResourceType(_ context.Context) *v2.ResourceType {
+	return t.resourceType
+}
+
+// Create a new connector resource for a trusted cluster, the connection from
+// this (leaf) cluster to a root cluster. The profile carries its role map.
+func getTrustedClusterResource(cluster types.TrustedCluster) (*v2.Resource, error) {
+	roleMap := make(map[string]interface{}, len(cluster.GetRoleMap()))
+	for _, mapping := range cluster.GetRoleMap() {
+		locals, _ := roleMap[mapping.Remote].([]interface{})
+		roleMap[mapping.Remote] = append(locals, stringsToInterfaces(mapping.Local)...)
+	}
+
+	return rs.NewRoleResource(
+		cluster.GetName(),
+		trustedClusterResourceType,
+		cluster.GetName(),
+		[]rs.RoleTraitOption{
+			rs.WithRoleProfile(map[string]interface{}{
+				"cluster_name":           cluster.GetName(),
+				"enabled":                cluster.GetEnabled(),
+				"proxy_address":          cluster.GetProxyAddress(),
+				"reverse_tunnel_address": cluster.GetReverseTunnelAddress(),
+				"role_map":               roleMap,
+			}),
+		},
+	)
+}
+
+// List returns the trusted clusters of a cluster one page at a time as
+// resource objects. When leaf clusters are synced, they are read from each
+// leaf cluster, where they are stored, and nested under it.
+func (t *trustedClusterBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, opts rs.SyncOpAttrs) ([]*v2.Resource, *rs.SyncOpResults, error) {
+	var rv []*v2.Resource
+	if t.clusters.skipList(trustedClusterResourceType.Id, parentResourceID) {
+		return nil, nil, nil
+	}
+
+	scope, err := t.clusters.scope(ctx, trustedClusterResourceType.Id, parentResourceID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	clusters, nextToken, err := scope.client.GetTrustedClustersPage(ctx, &pagination.Token{Token: opts.PageToken.Token, Size: opts.PageToken.Size})
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to list trusted clusters: %w", err)
+	}
+
+	for _, cluster := range clusters {
+		cr, err := getTrustedClusterResource(cluster)
+		if err != nil {
+			return nil, nil, fmt.Errorf("baton-teleport: failed to create trusted cluster resource: %w", err)
+		}
+		rv = append(rv, scope.scopeResource(cr))
+	}
+
+	return rv, &rs.SyncOpResults{NextPageToken: nextToken}, nil
+}
+
+func (t *trustedClusterBuilder) Get(ctx context.Context, resourceId *v2.ResourceId, _ *v2.ResourceId) (*v2.Resource, annotations.Annotations, error) {
+	if resourceId == nil {
+		return nil, nil, fmt.Errorf("baton-teleport: resourceId is required")
+	}
+
+	scope, name, err := t.clusters.scopeOf(ctx, trustedClusterResourceType.Id, resourceId.Resource)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cluster, err := scope.client.GetTrustedCluster(ctx, name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to get trusted cluster %s: %w", resourceId.Resource, err)
+	}
+
+	cr, err := getTrustedClusterResource(cluster)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to create trusted cluster resource: %w", err)
+	}
+
+	return scope.scopeResource(cr), nil, nil
+}
+
+func (t *trustedClusterBuilder) Entitlements(_ context.Context, _ *v2.Resource, _ rs.SyncOpAttrs) ([]*v2.Entitlement, *rs.SyncOpResults, error) {
+	return nil, nil, nil
+}
+
+// Grants returns the trusted cluster's role map as grants of each local role
+// to the remote role it is mapped from, expanded to the members of the
+// remote role. Users of the root cluster holding the remote role reach the
+// leaf cluster with the local roles.
+func (t *trustedClusterBuilder) Grants(ctx context.Context, resource *v2.Resource, _ rs.SyncOpAttrs) ([]*v2.Grant, *rs.SyncOpResults, error) {
+	scope, err := t.clusters.resourceScope(ctx, resource)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cluster, err := scope.client.GetTrustedCluster(ctx, scope.teleportName(resource.Id.Resource))
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to get trusted cluster %s: %w", resource.Id.Resource, err)
+	}
+
+	return roleMapGrants(scope, cluster.GetName(), cluster.GetRoleMap()), nil, nil
+}
+
+// roleMapGrants returns one grant per remote and local role pair of the role
+// map. Local roles are roles of the cluster in scope, the leaf; remote roles
+// are roles of the root cluster, whose IDs are their names. Wildcard and
+// regular expression mappings match roles of the other cluster that this
+// sync cannot list, so they are left out.
+func roleMapGrants(scope *clusterScope, clusterName string, roleMap types.RoleMap) []*v2.Grant {
+	var rv []*v2.Grant
+	seen := map[string]bool{}
+	for _, mapping := range roleMap {
+		if !isLiteralRoleName(mapping.Remote) {
+			continue
+		}
+		for _, local := range mapping.Local {
+			if !isLiteralRoleName(local) || seen[mapping.Remote+"\x00"+local] {
+				continue
+			}
+			seen[mapping.Remote+"\x00"+local] = true
+
+			localRole := &v2.Resource{Id: &v2.ResourceId{ResourceType: roleResourceType.Id, Resource: scope.resourceID(local)}}
+			rv = append(rv, newRoleGrant(localRole, roleMembership, mapping.Remote, grant.WithGrantMetadata(map[string]interface{}{
+				trustedClusterKey: clusterName,
+			})))
+		}
+	}
+	return rv
+}
+
+// isLiteralRoleName reports whether name is a plain role name rather than a
+// wildcard, a regular expression or a reference to a regular expression
+// capture.
+func isLiteralRoleName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, "*^$")
+}
+
+func newTrustedClusterBuilder(clusters *clusterSet) *trustedClusterBuilder {
+	return &trustedClusterBuilder{
+		resourceType: trustedClusterResourceType,
+		clusters:     clusters,
+	}
+}
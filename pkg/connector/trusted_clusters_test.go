@@ -0,0 +1,33 @@
+package connector
+
+import (
+	"testing"
+
+	"github.com/gravitational/teleport/api/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoleMapGrants(t *testing.T) {
+	roleMap := types.RoleMap{
+		{Remote: "admin", Local: []string{"leaf-admin", "access"}},
+		{Remote: "admin", Local: []string{"access"}},
+		{Remote: "*", Local: []string{"access"}},
+		{Remote: "^dev-(.*)$", Local: []string{"$1"}},
+		{Remote: "auditor", Local: []string{"$1", "leaf-auditor"}},
+	}
+
+	grants := roleMapGrants(&clusterScope{name: "leaf.example.com", leaf: true}, "root", roleMap)
+	require.Len(t, grants, 3)
+
+	var pairs [][2]string
+	for _, g := range grants {
+		require.Equal(t, roleResourceType.Id, g.Principal.Id.ResourceType)
+		require.Equal(t, "root", grantMetadata(t, g)[trustedClusterKey])
+		pairs = append(pairs, [2]string{g.Principal.Id.Resource, g.Entitlement.Resource.Id.Resource})
+	}
+	require.Equal(t, [][2]string{
+		{"admin", "leaf.example.com/leaf-admin"},
+		{"admin", "leaf.example.com/access"},
+		{"auditor", "leaf.example.com/leaf-auditor"},
+	}, pairs)
+}
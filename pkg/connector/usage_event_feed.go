@@ -19,14 +19,14 @@ import (
 )
 
 const (
-	usageEventFeedID        = "teleport_usage_events"
-	eventsPageSize          = 100
-	userLoginEventType      = "user.login"
-	teleportClusterResource = "teleport_cluster"
+	usageEventFeedID   = "teleport_usage_events"
+	eventsPageSize     = 100
+	userLoginEventType = "user.login"
 )
 
 type usageEventFeed struct {
-	client *client.TeleportClient
+	client   *client.TeleportClient
+	clusters *clusterSet
 }
 
 func (e *usageEventFeed) EventFeedMetadata(_ context.Context) *v2.EventFeedMetadata {
@@ -56,6 +56,11 @@ func (e *usageEventFeed) ListEvents(
 	}
 	to := time.Now().UTC()
 
+	rootName, err := e.clusters.rootClusterName(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
 	auditEvents, lastKey, err := e.client.SearchEvents(
 		ctx,
 		from,
@@ -78,7 +83,7 @@ func (e *usageEventFeed) ListEvents(
 
 	var result []*v2.Event
 	for _, auditEvent := range auditEvents {
-		evt, eventTime := convertToUsageEvent(auditEvent)
+		evt, eventTime := convertToUsageEvent(auditEvent, rootName)
 		cursor.updateLatestEvent(eventTime)
 		if evt != nil {
 			result = append(result, evt)
@@ -102,7 +107,10 @@ func (e *usageEventFeed) ListEvents(
 	}, nil, nil
 }
 
-func convertToUsageEvent(auditEvent events.AuditEvent) (*v2.Event, time.Time) {
+// convertToUsageEvent turns a successful login into a usage event of the
+// user on the cluster resource the login was made to, which is the root
+// cluster when the event does not name one.
+func convertToUsageEvent(auditEvent events.AuditEvent, rootName string) (*v2.Event, time.Time) {
 	userLogin, ok := auditEvent.(*events.UserLogin)
 	if !ok {
 		return nil, time.Time{}
@@ -116,7 +124,7 @@ func convertToUsageEvent(auditEvent events.AuditEvent) (*v2.Event, time.Time) {
 
 	clusterName := userLogin.GetClusterName()
 	if clusterName == "" {
-		clusterName = "teleport"
+		clusterName = rootName
 	}
 
 	return &v2.Event{
@@ -126,7 +134,7 @@ func convertToUsageEvent(auditEvent events.AuditEvent) (*v2.Event, time.Time) {
 			UsageEvent: &v2.UsageEvent{
 				TargetResource: &v2.Resource{
 					Id: &v2.ResourceId{
-						ResourceType: teleportClusterResource,
+						ResourceType: clusterResourceType.Id,
 						Resource:     clusterName,
 					},
 					DisplayName: clusterName,
@@ -144,5 +152,5 @@ func convertToUsageEvent(auditEvent events.AuditEvent) (*v2.Event, time.Time) {
 }
 
 func newUsageEventFeed(c *client.TeleportClient) *usageEventFeed {
-	return &usageEventFeed{client: c}
+	return &usageEventFeed{client: c, clusters: newClusterSet(c, false)}
 }
@@ -91,6 +91,41 @@ func TestEventPageCursor_MarshalRoundtrip(t *testing.T) {
 	require.Equal(t, c.LastKey, decoded.LastKey)
 }
 
+// --- auditFeedCursor ---
+
+func TestAuditFeedCursor_ResumesRootCursor(t *testing.T) {
+	// A cursor stored before leaf clusters were read resumes the root log.
+	encoded, err := (&eventPageCursor{StartAt: "2024-03-01T00:00:00Z", LastKey: "root-key"}).marshal()
+	require.NoError(t, err)
+
+	c, err := unmarshalAuditFeedCursor(&pagination.StreamToken{Cursor: encoded}, nil)
+	require.NoError(t, err)
+	require.Equal(t, "2024-03-01T00:00:00Z", c.StartAt)
+	require.Equal(t, "root-key", c.LastKey)
+	require.Empty(t, c.Leaves)
+}
+
+func TestAuditFeedCursor_LeafPositions(t *testing.T) {
+	defaultStart := timestamppb.New(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	c, err := unmarshalAuditFeedCursor(nil, defaultStart)
+	require.NoError(t, err)
+
+	// A leaf seen for the first time starts at the default start.
+	leaf := c.leaf("leaf.example.com", defaultStart)
+	require.Equal(t, "2024-01-01T00:00:00Z", leaf.StartAt)
+
+	leaf.LastKey = "leaf-key"
+	c.Leaves = map[string]*eventPageCursor{"leaf.example.com": leaf}
+	encoded, err := c.marshal()
+	require.NoError(t, err)
+
+	decoded, err := unmarshalAuditFeedCursor(&pagination.StreamToken{Cursor: encoded}, nil)
+	require.NoError(t, err)
+	require.Equal(t, c.StartAt, decoded.StartAt)
+	require.Empty(t, decoded.LastKey)
+	require.Equal(t, "leaf-key", decoded.leaf("leaf.example.com", nil).LastKey)
+}
+
 // --- updateLatestEvent ---
 
 func TestUpdateLatestEvent_UpdatesWhenNewer(t *testing.T) {
@@ -161,7 +196,7 @@ func TestConvertToUsageEvent_Success(t *testing.T) {
 		},
 	}
 
-	evt, ts := convertToUsageEvent(login)
+	evt, ts := convertToUsageEvent(login, "root.example.com")
 	require.NotNil(t, evt)
 	require.Equal(t, "event-abc", evt.Id)
 	require.Equal(t, eventTime.Unix(), ts.Unix())
@@ -170,7 +205,7 @@ func TestConvertToUsageEvent_Success(t *testing.T) {
 	require.NotNil(t, usageEvt)
 
 	require.NotNil(t, usageEvt.TargetResource)
-	require.Equal(t, teleportClusterResource, usageEvt.TargetResource.Id.ResourceType)
+	require.Equal(t, clusterResourceType.Id, usageEvt.TargetResource.Id.ResourceType)
 	require.Equal(t, "my-cluster", usageEvt.TargetResource.Id.Resource)
 	require.Equal(t, "my-cluster", usageEvt.TargetResource.DisplayName)
 
@@ -186,14 +221,14 @@ func TestConvertToUsageEvent_FailedLogin(t *testing.T) {
 		UserMetadata: events.UserMetadata{User: "bob"},
 		Status:       events.Status{Success: false},
 	}
-	evt, ts := convertToUsageEvent(login)
+	evt, ts := convertToUsageEvent(login, "root.example.com")
 	require.Nil(t, evt)
 	require.True(t, ts.IsZero())
 }
 
 func TestConvertToUsageEvent_WrongEventType(t *testing.T) {
 	other := &events.SessionStart{}
-	evt, ts := convertToUsageEvent(other)
+	evt, ts := convertToUsageEvent(other, "root.example.com")
 	require.Nil(t, evt)
 	require.True(t, ts.IsZero())
 }
@@ -204,7 +239,7 @@ func TestConvertToUsageEvent_EmptyClusterNameFallback(t *testing.T) {
 		UserMetadata: events.UserMetadata{User: "carol"},
 		Status:       events.Status{Success: true},
 	}
-	evt, _ := convertToUsageEvent(login)
+	evt, _ := convertToUsageEvent(login, "root.example.com")
 	require.NotNil(t, evt)
-	require.Equal(t, "teleport", evt.GetUsageEvent().TargetResource.Id.Resource)
+	require.Equal(t, "root.example.com", evt.GetUsageEvent().TargetResource.Id.Resource)
 }
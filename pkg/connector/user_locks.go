@@ -0,0 +1,242 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	config "github.com/conductorone/baton-sdk/pb/c1/config/v1"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/actions"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/gravitational/teleport/api/client/proto"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/trace"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/conductorone/baton-teleport/pkg/client"
+)
+
+const (
+	disableUserAction = "disable_user"
+	enableUserAction  = "enable_user"
+
+	userActionResourceKey = "resource_id"
+	userActionTTLKey      = "ttl"
+	userActionReasonKey   = "reason"
+	userActionLockKey     = "lock_name"
+	userActionSessionsKey = "terminated_sessions"
+
+	// userLockPrefix names the locks the connector manages, so that enabling
+	// a user never removes a lock somebody else placed.
+	userLockPrefix     = "baton-"
+	defaultLockMessage = "Your Teleport access has been suspended."
+)
+
+// userLockName returns the name of the lock the connector places on a user.
+func userLockName(userName string) string {
+	return userLockPrefix + userName
+}
+
+func userActionResourceArgument() *config.Field {
+	return config.Field_builder{
+		Name:            userActionResourceKey,
+		DisplayName:     "User",
+		Description:     "The Teleport user to act on.",
+		ResourceIdField: &config.ResourceIdField{},
+		IsRequired:      true,
+	}.Build()
+}
+
+func userActionReturnTypes(extra ...*config.Field) []*config.Field {
+	return append([]*config.Field{
+		config.Field_builder{
+			Name:        "success",
+			DisplayName: "Success",
+			BoolField:   &config.BoolField{},
+		}.Build(),
+		config.Field_builder{
+			Name:        userActionLockKey,
+			DisplayName: "Lock name",
+			StringField: &config.StringField{},
+		}.Build(),
+	}, extra...)
+}
+
+// ResourceActions registers the account disable and enable actions for
+// users. Disabling places a Teleport lock on the user, which rejects new
+// logins and certificates straight away without deleting the user or its
+// roles, and ends the sessions it is in; enabling removes that lock again.
+func (u *userBuilder) ResourceActions(ctx context.Context, registry actions.ActionRegistry) error {
+	err := registry.Register(ctx, v2.BatonActionSchema_builder{
+		Name:        disableUserAction,
+		DisplayName: "Disable user",
+		Description: "Suspend a Teleport user by placing a lock on it and terminating its active sessions.",
+		Arguments: []*config.Field{
+			userActionResourceArgument(),
+			config.Field_builder{
+				Name:        userActionTTLKey,
+				DisplayName: "Duration",
+				Description: "How long the user stays disabled, as a Go duration such as 24h. The lock never expires if empty.",
+				StringField: &config.StringField{},
+			}.Build(),
+			config.Field_builder{
+				Name:        userActionReasonKey,
+				DisplayName: "Reason",
+				Description: "The message shown to the user while it is locked out.",
+				StringField: &config.StringField{},
+			}.Build(),
+		},
+		ReturnTypes: userActionReturnTypes(config.Field_builder{
+			Name:        userActionSessionsKey,
+			DisplayName: "Terminated sessions",
+			IntField:    &config.IntField{},
+		}.Build()),
+		ActionType: []v2.ActionType{v2.ActionType_ACTION_TYPE_ACCOUNT_DISABLE},
+	}.Build(), u.disableUser)
+	if err != nil {
+		return err
+	}
+
+	return registry.Register(ctx, v2.BatonActionSchema_builder{
+		Name:        enableUserAction,
+		DisplayName: "Enable user",
+		Description: "Lift the lock placed on a Teleport user by Disable user.",
+		Arguments:   []*config.Field{userActionResourceArgument()},
+		ReturnTypes: userActionReturnTypes(),
+		ActionType:  []v2.ActionType{v2.ActionType_ACTION_TYPE_ACCOUNT_ENABLE},
+	}.Build(), u.enableUser)
+}
+
+// disableUser locks the user under the connector's lock name, replacing any
+// earlier lock of the same name, then terminates the sessions the user hosts
+// or takes part in. The lock already keeps the user from starting new ones.
+func (u *userBuilder) disableUser(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
+	userID, err := userActionTarget(args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	scope, userName, err := u.clusters.scopeOf(ctx, userResourceType.Id, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	spec := types.LockSpecV2{
+		Target:  types.LockTarget{User: userName},
+		Message: defaultLockMessage,
+	}
+	if reason, ok := actions.GetStringArg(args, userActionReasonKey); ok && reason != "" {
+		spec.Message = reason
+	}
+	if ttl, ok := actions.GetStringArg(args, userActionTTLKey); ok && ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil || d <= 0 {
+			return nil, nil, fmt.Errorf("baton-teleport: invalid %s %q: must be a positive duration such as 24h", userActionTTLKey, ttl)
+		}
+		expires := time.Now().Add(d)
+		spec.Expires = &expires
+	}
+
+	if _, err := scope.client.GetUser(ctx, userName, false); err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to get user %s: %w", userName, err)
+	}
+
+	lockName := userLockName(userName)
+	lock, err := types.NewLock(lockName, spec)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to create lock for user %s: %w", userName, err)
+	}
+
+	if err := scope.client.UpsertLock(ctx, lock); err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to lock user %s: %w", userName, err)
+	}
+
+	terminated, err := terminateSessions(ctx, scope.client, userName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return actions.NewReturnValues(true,
+		actions.NewStringReturnField(userActionLockKey, lockName),
+		actions.NewNumberReturnField(userActionSessionsKey, float64(terminated)),
+	), nil, nil
+}
+
+// terminateSessions marks every active session the user hosts or takes part
+// in as terminated, and returns how many it ended.
+func terminateSessions(ctx context.Context, c *client.TeleportClient, userName string) (int, error) {
+	trackers, err := c.GetActiveSessionTrackers(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("baton-teleport: failed to list active sessions: %w", err)
+	}
+
+	sessionIDs := userSessionIDs(trackers, userName)
+	for _, sessionID := range sessionIDs {
+		err := c.UpdateSessionTracker(ctx, &proto.UpdateSessionTrackerRequest{
+			SessionID: sessionID,
+			Update: &proto.UpdateSessionTrackerRequest_UpdateState{
+				UpdateState: &proto.SessionTrackerUpdateState{State: types.SessionState_SessionStateTerminated},
+			},
+		})
+		if err != nil && !trace.IsNotFound(err) {
+			return 0, fmt.Errorf("baton-teleport: failed to terminate session %s of user %s: %w", sessionID, userName, err)
+		}
+	}
+
+	return len(sessionIDs), nil
+}
+
+// userSessionIDs returns the sessions that are not yet terminated and that
+// the user hosts or participates in.
+func userSessionIDs(trackers []types.SessionTracker, userName string) []string {
+	var rv []string
+	for _, tracker := range trackers {
+		if tracker.GetState() == types.SessionState_SessionStateTerminated {
+			continue
+		}
+
+		participates := tracker.GetHostUser() == userName
+		for _, participant := range tracker.GetParticipants() {
+			if participant.User == userName {
+				participates = true
+			}
+		}
+		if participates {
+			rv = append(rv, tracker.GetSessionID())
+		}
+	}
+	return rv
+}
+
+// enableUser removes the connector's lock from the user. A user without one
+// is already enabled as far as the connector is concerned.
+func (u *userBuilder) enableUser(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
+	userID, err := userActionTarget(args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	scope, userName, err := u.clusters.scopeOf(ctx, userResourceType.Id, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lockName := userLockName(userName)
+	if err := scope.client.DeleteLock(ctx, lockName); err != nil && !trace.IsNotFound(err) {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to unlock user %s: %w", userName, err)
+	}
+
+	return actions.NewReturnValues(true, actions.NewStringReturnField(userActionLockKey, lockName)), nil, nil
+}
+
+func userActionTarget(args *structpb.Struct) (string, error) {
+	resourceID, err := actions.RequireResourceIDArg(args, userActionResourceKey)
+	if err != nil {
+		return "", fmt.Errorf("baton-teleport: %w", err)
+	}
+	if resourceID.GetResourceType() != userResourceType.Id || resourceID.GetResource() == "" {
+		return "", fmt.Errorf("baton-teleport: %s is not a user", resourceID.GetResource())
+	}
+	return resourceID.GetResource(), nil
+}
@@ -8,6 +8,7 @@ import (
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
 	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
 	"github.com/conductorone/baton-sdk/pkg/types/resource"
 	"github.com/conductorone/baton-teleport/pkg/client"
 	"github.com/gravitational/teleport/api/client/proto"
@@ -17,17 +18,18 @@ import (
 type userBuilder struct {
 	resourceType *v2.ResourceType
 	client       *client.TeleportClient
+	clusters     *clusterSet
 }
 
 func (o *userBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
 	return userResourceType
 }
 
-func userResource(pId *v2.ResourceId, user types.User) (*v2.Resource, error) {
-	var (
-		accountType = v2.UserTrait_ACCOUNT_TYPE_HUMAN
-		status      v2.UserTrait_Status_Status
-	)
+// userResource creates a connector resource for a Teleport user. A user
+// targeted by a lock in force is reported as disabled; the failed-login
+// lockout in the user's status is temporary and does not count.
+func userResource(pId *v2.ResourceId, user types.User, locked bool) (*v2.Resource, error) {
+	accountType := v2.UserTrait_ACCOUNT_TYPE_HUMAN
 
 	if user.IsBot() {
 		accountType = v2.UserTrait_ACCOUNT_TYPE_SERVICE
@@ -47,18 +49,19 @@ func userResource(pId *v2.ResourceId, user types.User) (*v2.Resource, error) {
 		"last_name":  lastName,
 	}
 
+	// OS logins granted through the {{internal.logins}} trait template.
+	if logins := user.GetLogins(); len(logins) > 0 {
+		profile["logins"] = stringsToInterfaces(logins)
+	}
+
 	// Teleport does not store an email natively for users.
 	if accountType == v2.UserTrait_ACCOUNT_TYPE_HUMAN {
 		profile["email"] = name
 	}
 
-	switch user.GetStatus().IsLocked {
-	case true:
+	status := v2.UserTrait_Status_STATUS_ENABLED
+	if locked {
 		status = v2.UserTrait_Status_STATUS_DISABLED
-	case false:
-		status = v2.UserTrait_Status_STATUS_ENABLED
-	default:
-		status = v2.UserTrait_Status_STATUS_UNSPECIFIED
 	}
 
 	opts := []resource.UserTraitOption{
@@ -112,7 +115,7 @@ func (u *userBuilder) CreateAccount(
 		return nil, nil, nil, fmt.Errorf("failed to create reset password token: %w", err)
 	}
 
-	userRes, err := userResource(nil, newUser)
+	userRes, err := userResource(nil, newUser, false)
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -162,31 +165,45 @@ func createNewUserInfo(accountInfo *v2.AccountInfo) (*types.UserV2, error) {
 	}, nil
 }
 
-func (u *userBuilder) Get(ctx context.Context, resourceId *v2.ResourceId, parentResourceId *v2.ResourceId) (*v2.Resource, annotations.Annotations, error) {
+func (u *userBuilder) Get(ctx context.Context, resourceId *v2.ResourceId, _ *v2.ResourceId) (*v2.Resource, annotations.Annotations, error) {
 	if resourceId == nil {
 		return nil, nil, fmt.Errorf("baton-teleport: resourceId is required")
 	}
 
-	user, err := u.client.GetUser(ctx, resourceId.Resource, false)
+	scope, name, err := u.clusters.scopeOf(ctx, userResourceType.Id, resourceId.Resource)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err := scope.client.GetUser(ctx, name, false)
 	if err != nil {
 		return nil, nil, fmt.Errorf("baton-teleport: failed to get user %s: %w", resourceId.Resource, err)
 	}
 
-	r, err := userResource(parentResourceId, user)
+	locked, err := lockedUsers(ctx, scope.client, user.GetName())
 	if err != nil {
 		return nil, nil, err
 	}
 
-	return r, nil, nil
+	r, err := userResource(nil, user, locked[user.GetName()])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return scope.scopeResource(r), nil, nil
 }
 
 func (u *userBuilder) Delete(ctx context.Context, resourceID *v2.ResourceId, _ *v2.ResourceId) (annotations.Annotations, error) {
-	username := resourceID.GetResource()
-	if username == "" {
+	if resourceID.GetResource() == "" {
 		return nil, fmt.Errorf("missing resource name")
 	}
 
-	user, err := u.client.GetUser(ctx, username, false)
+	scope, username, err := u.clusters.scopeOf(ctx, userResourceType.Id, resourceID.GetResource())
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := scope.client.GetUser(ctx, username, false)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
@@ -195,7 +212,7 @@ func (u *userBuilder) Delete(ctx context.Context, resourceID *v2.ResourceId, _ *
 		return nil, fmt.Errorf("cannot delete federated (non-local) user: %s", username)
 	}
 
-	err = u.client.DeleteUser(ctx, username)
+	err = scope.client.DeleteUser(ctx, username)
 	if err != nil {
 		return nil, fmt.Errorf("failed to delete user: %w", err)
 	}
@@ -203,26 +220,70 @@ func (u *userBuilder) Delete(ctx context.Context, resourceID *v2.ResourceId, _ *
 	return nil, nil
 }
 
-// List returns all the users from the database as resource objects.
-// Users include a UserTrait because they are the 'shape' of a standard user.
-func (u *userBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, _ resource.SyncOpAttrs) ([]*v2.Resource, *resource.SyncOpResults, error) {
+// List returns the users from the database one page at a time as resource
+// objects. Users include a UserTrait because they are the 'shape' of a
+// standard user.
+func (u *userBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, opts resource.SyncOpAttrs) ([]*v2.Resource, *resource.SyncOpResults, error) {
 	var rv []*v2.Resource
-	users, err := u.client.GetUsers(ctx, false)
+	if u.clusters.skipList(userResourceType.Id, parentResourceID) {
+		return nil, nil, nil
+	}
+
+	scope, err := u.clusters.scope(ctx, userResourceType.Id, parentResourceID)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	users, nextToken, err := scope.client.GetUsersPage(ctx, &pagination.Token{Token: opts.PageToken.Token, Size: opts.PageToken.Size})
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to list users: %w", err)
+	}
+
+	names := make([]string, 0, len(users))
 	for _, user := range users {
-		userCopy := user
-		ur, err := userResource(parentResourceID, userCopy)
+		names = append(names, user.GetName())
+	}
+	locked, err := lockedUsers(ctx, scope.client, names...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, user := range users {
+		ur, err := userResource(nil, user, locked[user.GetName()])
 		if err != nil {
 			return nil, nil, err
 		}
 
-		rv = append(rv, ur)
+		rv = append(rv, scope.scopeResource(ur))
+	}
+
+	return rv, &resource.SyncOpResults{NextPageToken: nextToken}, nil
+}
+
+// lockedUsers reports which of the named users a lock in force targets. The locks of
+// all of them are listed at once, so a page of users costs one listing.
+func lockedUsers(ctx context.Context, c *client.TeleportClient, users ...string) (map[string]bool, error) {
+	if len(users) == 0 {
+		return nil, nil
 	}
 
-	return rv, nil, nil
+	targets := make([]types.LockTarget, 0, len(users))
+	for _, user := range users {
+		targets = append(targets, types.LockTarget{User: user})
+	}
+
+	locks, err := c.GetInForceLocks(ctx, targets...)
+	if err != nil {
+		return nil, fmt.Errorf("baton-teleport: failed to list user locks: %w", err)
+	}
+
+	locked := make(map[string]bool, len(locks))
+	for _, lock := range locks {
+		if user := lock.Target().User; user != "" {
+			locked[user] = true
+		}
+	}
+	return locked, nil
 }
 
 // Entitlements always returns an empty slice for users.
@@ -235,9 +296,10 @@ func (o *userBuilder) Grants(_ context.Context, _ *v2.Resource, _ resource.SyncO
 	return nil, nil, nil
 }
 
-func newUserBuilder(c *client.TeleportClient) *userBuilder {
+func newUserBuilder(c *client.TeleportClient, clusters *clusterSet) *userBuilder {
 	return &userBuilder{
 		resourceType: userResourceType,
 		client:       c,
+		clusters:     clusters,
 	}
 }
@@ -0,0 +1,161 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/gravitational/teleport/api/types"
+
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+// windowsDesktopIDSeparator separates the host ID from the desktop name in
+// desktop resource IDs.
+const windowsDesktopIDSeparator = "/"
+
+// windowsDesktopValueKinds models every Windows login a desktop accepts as its
+// own entitlement. Reaching a desktop is only meaningful with a login, so
+// desktops have no separate membership entitlement.
+var windowsDesktopValueKinds = []roleValueKind{
+	{prefix: "login", description: "Windows login", values: types.Role.GetWindowsLogins},
+}
+
+type windowsDesktopBuilder struct {
+	resourceType *v2.ResourceType
+	clusters     *clusterSet
+}
+
+func (w *windowsDesktopBuilder) ResourceType(_ context.Context) *v2.ResourceType {
+	return w.resourceType
+}
+
+// windowsDesktopID returns the resource ID of a desktop. Teleport keys
+// desktops by the ID of the Windows Desktop Service host and the desktop
+// name, and the same name may be registered by several hosts.
+func windowsDesktopID(desktop types.WindowsDesktop) string {
+	return desktop.GetHostID() + windowsDesktopIDSeparator + desktop.GetName()
+}
+
+// parseWindowsDesktopID splits a resource ID built by windowsDesktopID into
+// the host ID and the desktop name.
+func parseWindowsDesktopID(id string) (string, string, error) {
+	hostID, name, ok := strings.Cut(id, windowsDesktopIDSeparator)
+	if !ok || hostID == "" || name == "" {
+		return "", "", fmt.Errorf("baton-teleport: invalid windows desktop id %q", id)
+	}
+	return hostID, name, nil
+}
+
+// Create a new connector resource for a Teleport Windows desktop.
+func getWindowsDesktopResource(desktop types.WindowsDesktop) (*v2.Resource, error) {
+	return rs.NewRoleResource(
+		desktop.GetName(),
+		windowsDesktopResourceType,
+		windowsDesktopID(desktop),
+		[]rs.RoleTraitOption{
+			rs.WithRoleProfile(
+				map[string]interface{}{
+					"desktop_name":   desktop.GetName(),
+					"addr":           desktop.GetAddr(),
+					"domain":         desktop.GetDomain(),
+					"host_id":        desktop.GetHostID(),
+					"non_ad":         desktop.NonAD(),
+					labelsProfileKey: labelsProfile(desktop.GetAllLabels()),
+				},
+			),
+		},
+	)
+}
+
+// List returns all the Windows desktops registered in Teleport as resource objects.
+func (w *windowsDesktopBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, opts rs.SyncOpAttrs) ([]*v2.Resource, *rs.SyncOpResults, error) {
+	var rv []*v2.Resource
+	if w.clusters.skipList(w.resourceType.Id, parentResourceID) {
+		return nil, nil, nil
+	}
+
+	scope, err := w.clusters.scope(ctx, w.resourceType.Id, parentResourceID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := scope.client.GetWindowsDesktopsPage(ctx, &pagination.Token{Token: opts.PageToken.Token, Size: opts.PageToken.Size})
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to list windows desktops: %w", err)
+	}
+
+	for _, desktop := range resp.Desktops {
+		rr, err := getWindowsDesktopResource(desktop)
+		if err != nil {
+			return nil, nil, fmt.Errorf("baton-teleport: failed to create windows desktop resource: %w", err)
+		}
+		rv = append(rv, scope.scopeResource(rr))
+	}
+
+	return rv, &rs.SyncOpResults{NextPageToken: resp.NextKey}, nil
+}
+
+func (w *windowsDesktopBuilder) Get(ctx context.Context, resourceId *v2.ResourceId, _ *v2.ResourceId) (*v2.Resource, annotations.Annotations, error) {
+	if resourceId == nil {
+		return nil, nil, fmt.Errorf("baton-teleport: resourceId is required")
+	}
+
+	scope, id, err := w.clusters.scopeOf(ctx, w.resourceType.Id, resourceId.Resource)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hostID, name, err := parseWindowsDesktopID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	desktops, err := scope.client.GetWindowsDesktops(ctx, types.WindowsDesktopFilter{HostID: hostID, Name: name})
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to get windows desktop %s: %w", resourceId.Resource, err)
+	}
+	if len(desktops) == 0 {
+		return nil, nil, fmt.Errorf("baton-teleport: windows desktop %s not found", resourceId.Resource)
+	}
+
+	res, err := getWindowsDesktopResource(desktops[0])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return scope.scopeResource(res), nil, nil
+}
+
+// Entitlements returns one entitlement per Windows login allowed by a role
+// whose windows_desktop_labels match the desktop.
+func (w *windowsDesktopBuilder) Entitlements(ctx context.Context, resource *v2.Resource, opts rs.SyncOpAttrs) ([]*v2.Entitlement, *rs.SyncOpResults, error) {
+	access, err := resolveResourceAccess(ctx, w.clusters, resource, types.KindWindowsDesktop, windowsDesktopValueKinds, opts.SyncID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to compute windows desktop entitlements: %w", err)
+	}
+
+	return access.entitlements(resource, windowsDesktopValueKinds), nil, nil
+}
+
+// Grants returns the Windows login grants of every role whose
+// windows_desktop_labels match the desktop. Logins expanded from a trait
+// template such as {{internal.windows_logins}} are granted to users directly.
+func (w *windowsDesktopBuilder) Grants(ctx context.Context, resource *v2.Resource, opts rs.SyncOpAttrs) ([]*v2.Grant, *rs.SyncOpResults, error) {
+	access, err := resolveResourceAccess(ctx, w.clusters, resource, types.KindWindowsDesktop, windowsDesktopValueKinds, opts.SyncID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-teleport: failed to compute windows desktop grants: %w", err)
+	}
+
+	return access.grants(resource, "", windowsDesktopValueKinds), nil, nil
+}
+
+func newWindowsDesktopBuilder(clusters *clusterSet) *windowsDesktopBuilder {
+	return &windowsDesktopBuilder{
+		resourceType: windowsDesktopResourceType,
+		clusters:     clusters,
+	}
+}
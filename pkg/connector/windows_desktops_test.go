@@ -0,0 +1,36 @@
+package connector
+
+import (
+	"testing"
+
+	"github.com/gravitational/teleport/api/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetWindowsDesktopResource_IDIncludesHost(t *testing.T) {
+	newDesktop := func(hostID string) types.WindowsDesktop {
+		desktop, err := types.NewWindowsDesktopV3("jump-01", nil, types.WindowsDesktopSpecV3{
+			Addr:   "10.0.0.5:3389",
+			HostID: hostID,
+		})
+		require.NoError(t, err)
+		return desktop
+	}
+
+	first, err := getWindowsDesktopResource(newDesktop("host-a"))
+	require.NoError(t, err)
+	second, err := getWindowsDesktopResource(newDesktop("host-b"))
+	require.NoError(t, err)
+
+	require.Equal(t, "host-a/jump-01", first.Id.Resource)
+	require.Equal(t, "host-b/jump-01", second.Id.Resource)
+	require.Equal(t, "jump-01", first.DisplayName)
+
+	hostID, name, err := parseWindowsDesktopID(second.Id.Resource)
+	require.NoError(t, err)
+	require.Equal(t, "host-b", hostID)
+	require.Equal(t, "jump-01", name)
+
+	_, _, err = parseWindowsDesktopID("jump-01")
+	require.Error(t, err)
+}